@@ -0,0 +1,242 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/thejasmeetsingh/oclai/pkg/ollama"
+	"github.com/thejasmeetsingh/oclai/pkg/utils"
+)
+
+// toolStatus is the state of a tool call shown in the chat history
+type toolStatus int
+
+const (
+	toolRunning toolStatus = iota
+	toolDone
+	toolError
+	toolRejected
+)
+
+type (
+	// toolActivity is a tool call shown in the chat history. The block is collapsed to a summary
+	// of the call and its result, and expanded to show the full tool input and output.
+	toolActivity struct {
+		number   int // Position of the block in the chat history, used to expand it
+		id       int // Identifies the call among the calls of the request
+		name     string
+		args     map[string]any
+		output   string // Result or error of the call
+		status   toolStatus
+		duration time.Duration
+		expanded bool
+	}
+
+	// toolEventMsg carries the progress of a tool call made during a chat request
+	toolEventMsg struct {
+		event toolEvent
+	}
+)
+
+// newToolActivity creates the block of the given tool call, as running
+func newToolActivity(id int, call ollama.ToolCall) *toolActivity {
+	return &toolActivity{
+		id:     id,
+		name:   call.Function.Name,
+		args:   call.Function.Args,
+		status: toolRunning,
+	}
+}
+
+// statusText describes the state of the tool call along with its duration
+func (t *toolActivity) statusText() string {
+	switch t.status {
+	case toolRunning:
+		return "⏳ running"
+	case toolRejected:
+		return "⛔ denied"
+	case toolError:
+		if t.duration == 0 {
+			return "✗ failed"
+		}
+		return "✗ failed after " + t.duration.Round(time.Millisecond).String()
+	default:
+		if t.duration == 0 {
+			return "✓ done"
+		}
+		return "✓ " + t.duration.Round(time.Millisecond).String()
+	}
+}
+
+// summaryLine returns the first non-empty line of the given text, truncated to the given width
+func summaryLine(text string, width int) string {
+	for line := range strings.SplitSeq(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return ansi.Truncate(line, width, "…")
+		}
+	}
+
+	return ""
+}
+
+// render formats the tool call block, the expanded input and output are wrapped at the given width
+func (t *toolActivity) render(wrapWidth int) string {
+	header := fmt.Sprintf("🔧 #%d %s  %s", t.number, t.name, t.statusText())
+
+	if !t.expanded {
+		args, _ := json.Marshal(t.args)
+		summary := summaryLine(string(args), wrapWidth-4)
+		if output := summaryLine(t.output, wrapWidth-6); output != "" {
+			summary += "\n↳ " + output
+		}
+
+		return utils.ToolMsgBox(header, summary)
+	}
+
+	args, _ := json.MarshalIndent(t.args, "", "  ")
+	output := t.output
+	if output == "" {
+		output = "(no output)"
+	}
+
+	details := fmt.Sprintf("**Input**\n```json\n%s\n```\n**Output**\n%s", args, utils.CodeBlock(output))
+	return utils.ToolMsgBox(header, getMarkdownString(details, wrapWidth-2))
+}
+
+// addToolActivity appends the given tool call block to the chat history, numbering it
+func (s *session) addToolActivity(activity *toolActivity) {
+	activity.number = len(s.toolActivities()) + 1
+	s.updateSessionMessages(sessionMessage{
+		_type: toolMsg,
+		tool:  activity,
+	})
+}
+
+// toolActivities returns the tool call blocks of the chat history, in order
+func (s *session) toolActivities() []*toolActivity {
+	var activities []*toolActivity
+	for _, message := range s.history {
+		if message._type == toolMsg {
+			activities = append(activities, message.tool)
+		}
+	}
+
+	return activities
+}
+
+// handleToolEvent shows the progress of a tool call in the chat history
+func (s *session) handleToolEvent(event toolEvent) {
+	if event.kind == toolStarted || event.kind == toolDenied {
+		// Keep the text streamed before the tool call above its block
+		if content := strings.TrimSpace(s.streamContent); content != "" {
+			s.updateSessionMessages(sessionMessage{
+				_type:   aiMsg,
+				content: content,
+			})
+		}
+		s.streamContent = ""
+
+		activity := newToolActivity(event.id, event.call)
+		if event.kind == toolDenied {
+			activity.status = toolRejected
+			activity.output = event.result
+			s.spinnerMsg = "Thinking"
+		} else {
+			s.spinnerMsg = "Running tool " + event.call.Function.Name
+		}
+
+		s.addToolActivity(activity)
+		return
+	}
+
+	// Update the block of the call, the most recent one with this ID
+	activities := s.toolActivities()
+	for i := len(activities) - 1; i >= 0; i-- {
+		activity := activities[i]
+		if activity.id != event.id || activity.status != toolRunning {
+			continue
+		}
+
+		activity.duration = event.duration
+		if event.kind == toolFailed {
+			activity.status = toolError
+			activity.output = event.err.Error()
+		} else {
+			activity.status = toolDone
+			activity.output = event.result
+		}
+		break
+	}
+
+	s.spinnerMsg = "Thinking"
+	s.rerenderHistory()
+}
+
+// toggleToolActivities expands all the tool call blocks, or collapses them if they are all expanded
+func (s *session) toggleToolActivities() {
+	activities := s.toolActivities()
+
+	expand := false
+	for _, activity := range activities {
+		if !activity.expanded {
+			expand = true
+		}
+	}
+
+	for _, activity := range activities {
+		activity.expanded = expand
+	}
+
+	s.rerenderHistory()
+}
+
+// handleToolToggle expands or collapses a tool call block, the most recent one if no number is provided
+func handleToolToggle(s *session, args []string) (*session, tea.Cmd) {
+	defer s.clearInput()
+
+	activities := s.toolActivities()
+	if len(activities) == 0 {
+		s.updateSessionMessages(sessionMessage{
+			_type:   errMsg,
+			content: "No tool calls in the chat history",
+		})
+		return s, nil
+	}
+
+	number := len(activities)
+	if len(args) != 0 {
+		var err error
+		number, err = strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+		if err != nil || number < 1 || number > len(activities) {
+			s.updateSessionMessages(sessionMessage{
+				_type:   errMsg,
+				content: fmt.Sprintf("Tool call number should be between 1 and %d", len(activities)),
+			})
+			return s, nil
+		}
+	}
+
+	activity := activities[number-1]
+	activity.expanded = !activity.expanded
+	s.rerenderHistory()
+
+	return s, nil
+}
+
+// restoreToolActivities adds the blocks of the tool calls requested by the given assistant message
+// to the chat history. They are queued to receive the results of the following tool messages.
+func (s *session) restoreToolActivities(message ollama.Message, pending []*toolActivity) []*toolActivity {
+	for _, call := range message.ToolCalls {
+		activity := newToolActivity(0, call)
+		activity.status = toolDone
+		s.addToolActivity(activity)
+		pending = append(pending, activity)
+	}
+
+	return pending
+}
@@ -0,0 +1,126 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/thejasmeetsingh/oclai/pkg/mcp"
+	"github.com/thejasmeetsingh/oclai/pkg/utils"
+)
+
+// answerPrinter prints the answer of a query as it is streamed. When the output is a terminal,
+// the streamed text is replaced by its markdown rendering at the terminal width once complete.
+// Otherwise it is kept raw, so it can be piped to another program.
+type answerPrinter struct {
+	out    io.Writer
+	render bool
+	size   func() (int, int) // Width and height of the terminal
+	text   strings.Builder   // Text streamed since the last flush
+}
+
+// newAnswerPrinter creates a printer writing to stdout, rendering the answer if stdout is a terminal
+func newAnswerPrinter() *answerPrinter {
+	return &answerPrinter{
+		out:    os.Stdout,
+		render: utils.IsTerminal(os.Stdout),
+		size:   utils.TerminalSize,
+	}
+}
+
+// write prints a streamed chunk of the answer as is
+func (p *answerPrinter) write(chunk string) {
+	fmt.Fprint(p.out, chunk)
+	p.text.WriteString(chunk)
+}
+
+// flush ends the streamed text, replacing it with its markdown rendering on a terminal.
+// It has to be called before printing anything else, so the streamed text is the last thing on the screen.
+func (p *answerPrinter) flush() {
+	text := p.text.String()
+	p.text.Reset()
+
+	if text == "" {
+		return
+	}
+
+	if !p.render || strings.TrimSpace(text) == "" {
+		p.endLine(text)
+		return
+	}
+
+	// The cursor cannot move back above the screen, so the text which scrolled out of it is kept as streamed
+	width, height := p.size()
+	rows := terminalRows(text, width)
+	if width <= 0 || rows > height {
+		p.endLine(text)
+		return
+	}
+
+	md, err := utils.ToMarkDown(text, width)
+	if err != nil {
+		p.endLine(text)
+		return
+	}
+
+	// Move back to the first row of the streamed text and clear it, before printing the rendering
+	if rows > 1 {
+		fmt.Fprintf(p.out, "\r\x1b[%dA\x1b[J", rows-1)
+	} else {
+		fmt.Fprint(p.out, "\r\x1b[J")
+	}
+
+	fmt.Fprint(p.out, md)
+}
+
+// toolEvent ends the text streamed before a tool call, so it is not joined with the text of the next step,
+// and reports the denied calls and the errors of the tools. The transport failures abort the query and are reported by it.
+func (p *answerPrinter) toolEvent(event toolEvent) {
+	var toolErr *mcp.ToolError
+
+	switch {
+	case event.kind == toolStarted:
+		p.flush()
+	case event.kind == toolFailed && errors.As(event.err, &toolErr):
+		p.flush()
+		fmt.Fprintln(p.out, utils.ErrorMessage(fmt.Sprintf("Tool '%s' failed: %s", event.call.Function.Name, event.err.Error())))
+	case event.kind == toolDenied:
+		p.flush()
+		fmt.Fprintln(p.out, utils.ErrorMessage(fmt.Sprintf("Tool '%s' denied: %s", event.call.Function.Name, event.result)))
+	}
+}
+
+// endLine terminates the given raw text with a new line, if it does not end with one
+func (p *answerPrinter) endLine(text string) {
+	if !strings.HasSuffix(text, "\n") {
+		fmt.Fprintln(p.out)
+	}
+}
+
+// terminalRows returns the number of terminal rows taken by the given text, starting on the first column.
+// The lines longer than the width wrap, and the tabs are expanded to the next multiple of 8 columns.
+func terminalRows(text string, width int) int {
+	rows := 0
+
+	for line := range strings.SplitSeq(text, "\n") {
+		columns := 0
+		parts := strings.Split(line, "\t")
+		for idx, part := range parts {
+			columns += ansi.StringWidth(part)
+			if idx < len(parts)-1 {
+				columns = (columns/8 + 1) * 8
+			}
+		}
+
+		if width <= 0 || columns <= width {
+			rows++
+		} else {
+			rows += (columns + width - 1) / width
+		}
+	}
+
+	return rows
+}
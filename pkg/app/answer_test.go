@@ -0,0 +1,136 @@
+package app
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/thejasmeetsingh/oclai/pkg/mcp"
+	"github.com/thejasmeetsingh/oclai/pkg/ollama"
+	"github.com/thejasmeetsingh/oclai/pkg/utils"
+)
+
+func TestTerminalRows(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		width int
+		want  int
+	}{
+		{"single line", "hello", 80, 1},
+		{"trailing new line", "hello\n", 80, 2},
+		{"several lines", "a\nb\nc", 80, 3},
+		{"wrapped line", "0123456789", 4, 3},
+		{"exact width", "0123", 4, 1},
+		{"tab expansion", "\tab", 8, 2},
+		{"wide characters", "日本語", 4, 2},
+		{"unknown width", "0123456789", 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := terminalRows(tt.text, tt.width); got != tt.want {
+				t.Errorf("terminalRows(%q, %d) = %d, want %d", tt.text, tt.width, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnswerPrinterKeepsRawOutput(t *testing.T) {
+	var out bytes.Buffer
+	printer := &answerPrinter{out: &out}
+
+	printer.write("# Title\n")
+	printer.write("Some **bold** text")
+	printer.flush()
+	printer.flush()
+
+	want := "# Title\nSome **bold** text\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestAnswerPrinterRendersOnTerminal(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		width      int
+		height     int
+		wantRender bool
+	}{
+		{"fits the screen", "# Title\nSome **bold** text", 80, 24, true},
+		{"wrapped lines fit the screen", strings.Repeat("word ", 30), 20, 24, true},
+		{"taller than the screen", strings.Repeat("- item\n", 30), 80, 24, false},
+		{"wrapped lines taller than the screen", strings.Repeat("word ", 300), 20, 24, false},
+		{"unknown size", "# Title", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			printer := &answerPrinter{
+				out:    &out,
+				render: true,
+				size:   func() (int, int) { return tt.width, tt.height },
+			}
+
+			printer.write(tt.text)
+			printer.flush()
+
+			// The rendering starts by clearing the streamed text
+			rendered := strings.TrimPrefix(out.String(), tt.text)
+			gotRender := strings.HasPrefix(rendered, "\r\x1b[")
+			if gotRender != tt.wantRender {
+				t.Fatalf("rendered = %t, want %t, output: %q", gotRender, tt.wantRender, out.String())
+			}
+
+			if !gotRender && !strings.HasSuffix(out.String(), "\n") {
+				t.Errorf("output = %q, want the raw text ending with a new line", out.String())
+			}
+		})
+	}
+}
+
+func TestAnswerPrinterSeparatesTheSteps(t *testing.T) {
+	call := ollama.ToolCall{}
+	call.Function.Name = "list_files"
+
+	tests := []struct {
+		name  string
+		event toolEvent
+		want  string
+	}{
+		{
+			name:  "started tool",
+			event: toolEvent{kind: toolStarted, call: call},
+			want:  "I'll list the files.\nThere are 2 files.\n",
+		},
+		{
+			name:  "failed tool",
+			event: toolEvent{kind: toolFailed, call: call, err: &mcp.ToolError{Message: "no such directory"}},
+			want:  "I'll list the files.\n" + utils.ErrorMessage("Tool 'list_files' failed: no such directory") + "\nThere are 2 files.\n",
+		},
+		{
+			name:  "denied tool",
+			event: toolEvent{kind: toolDenied, call: call, result: "not now"},
+			want:  "I'll list the files.\n" + utils.ErrorMessage("Tool 'list_files' denied: not now") + "\nThere are 2 files.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			printer := &answerPrinter{out: &out}
+
+			printer.write("I'll list the files.")
+			printer.toolEvent(tt.event)
+			printer.write("There are 2 files.")
+			printer.flush()
+
+			if out.String() != tt.want {
+				t.Errorf("output = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
@@ -2,54 +2,189 @@ package app
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
 
 	goMCP "github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/thejasmeetsingh/oclai/pkg/mcp"
 	"github.com/thejasmeetsingh/oclai/pkg/ollama"
 )
 
-// getToolResp retrieves the response from a tool call using the MCP session.
+// getToolResp retrieves the response from a tool call using the pooled MCP session.
 func getToolResp(ctx context.Context, tool ollama.ToolCall) (string, error) {
-	mcpSession, err := mcp.GetSessionFromToolName(ctx, tool.Function.Name)
-	if err != nil {
-		return "", err
-	}
-
 	toolParams := &goMCP.CallToolParams{
 		Name:      tool.Function.Name,
 		Arguments: tool.Function.Args,
 	}
 
-	return mcp.CallTool(ctx, mcpSession, toolParams)
+	return mcp.CallToolByName(ctx, toolParams)
 }
 
-// chatWithTools handles chat interactions with tools by recursively processing tool calls.
-func chatWithTools(ctx context.Context, request ollama.ModelRequest) (*ollama.ModelResponse, error) {
-	request.Stream = false
-	request.Options = map[string]any{"num_ctx": OclaiConfig.NumCtx}
+// finalAnswerPrompt asks the model to answer without any further tool calls
+const finalAnswerPrompt = "You have reached the limit of tool calls for this request. " +
+	"Do not call any more tools. Answer the original question using the information gathered so far."
+
+// toolEventKind is the stage of a tool call reported by the agent loop
+type toolEventKind int
 
-	response, err := ollama.Chat(OclaiConfig.BaseURL, request)
-	if err != nil {
-		return nil, err
+const (
+	toolStarted toolEventKind = iota
+	toolFinished
+	toolFailed
+	toolDenied
+)
+
+// toolEvent reports the progress of a tool call made by the agent loop.
+// A denied call is only reported by its denial, it is never started.
+type toolEvent struct {
+	kind     toolEventKind
+	id       int             // Identifies the call among the calls of the request
+	call     ollama.ToolCall // Tool name and arguments
+	result   string          // Output of the tool once finished, or the reason of the denial
+	err      error           // Error of the call, once failed
+	duration time.Duration   // Time taken by the call, once finished or failed
+}
+
+// agentOptions configures a single run of the agent loop
+type agentOptions struct {
+	maxSteps    int                  // Maximum number of tool-calling steps
+	onChunk     func(ollama.Message) // Receives the partial assistant messages as they are streamed
+	onToolEvent func(toolEvent)      // Notified when a tool call starts, finishes, fails or is denied
+
+	// approve asks the user whether a tool call can be executed, as required by the permission policy
+	approve func(context.Context, toolApproval) (approvalDecision, error)
+
+	// approvedTools holds the tools approved for the rest of the session, by "server/tool" name
+	approvedTools map[string]bool
+}
+
+// notifyToolEvent passes the given event to the tool event callback, if any
+func (opts agentOptions) notifyToolEvent(event toolEvent) {
+	if opts.onToolEvent != nil {
+		opts.onToolEvent(event)
 	}
+}
 
-	toolCalls := response.Message.ToolCalls
+// toolCallKey returns a key identifying a tool call by its name and arguments
+func toolCallKey(tool ollama.ToolCall) string {
+	args, _ := json.Marshal(tool.Function.Args)
+	return tool.Function.Name + string(args)
+}
+
+// isRepeatedStep checks whether every tool call of a step was already made during the request,
+// and marks the calls as seen.
+func isRepeatedStep(toolCalls []ollama.ToolCall, seenCalls map[string]bool) bool {
+	isRepeated := true
+
+	for _, tool := range toolCalls {
+		key := toolCallKey(tool)
+		if !seenCalls[key] {
+			isRepeated = false
+			seenCalls[key] = true
+		}
+	}
+
+	return isRepeated
+}
+
+// chatWithTools runs the agent loop: the model is queried and the requested tool calls are executed
+// until the model answers without tool calls. The loop is bounded by the maximum step count, and stops
+// early if the model keeps repeating identical tool calls. In both cases the model is asked for a
+// final answer without tools.
+//
+// Every tool exchange is recorded in the request messages: the assistant message containing
+// the tool calls, followed by one tool message per call. Since the messages are shared with
+// the caller, the exchange is kept in the conversation history for follow-up questions.
+func chatWithTools(ctx context.Context, request ollama.ModelRequest, opts agentOptions) (*ollama.ModelResponse, error) {
+	request.Options = map[string]any{"num_ctx": OclaiConfig.NumCtx}
+
+	seenCalls := make(map[string]bool)
+	callID := 0
+
+	for step := 0; step < opts.maxSteps; step++ {
+		response, err := ollama.ChatStream(ctx, OclaiConfig.BaseURL, request, opts.onChunk)
+		if err != nil {
+			return nil, err
+		}
+
+		toolCalls := response.Message.ToolCalls
+		if len(toolCalls) == 0 {
+			return response, nil
+		}
+
+		// Bail out of the loop if the model is only repeating the earlier tool calls
+		if isRepeatedStep(toolCalls, seenCalls) {
+			break
+		}
+
+		// Record the assistant message which requested the tool calls
+		*request.Messages = append(*request.Messages, response.Message)
 
-	if len(toolCalls) != 0 {
 		for _, tool := range toolCalls {
-			toolResp, err := getToolResp(ctx, tool)
+			// Stop processing the remaining tool calls if the request was cancelled
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+
+			callID++
+
+			// Check the permission of the call, the model is told about a denial so it can do without the tool
+			decision, err := opts.authorizeToolCall(ctx, tool)
 			if err != nil {
 				return nil, err
 			}
 
+			if !decision.allowed {
+				opts.notifyToolEvent(toolEvent{kind: toolDenied, id: callID, call: tool, result: decision.reason})
+				*request.Messages = append(*request.Messages, ollama.Message{
+					Role:     ollama.ToolRole,
+					Content:  deniedToolResponse(decision.reason),
+					ToolName: tool.Function.Name,
+				})
+				continue
+			}
+
+			opts.notifyToolEvent(toolEvent{kind: toolStarted, id: callID, call: tool})
+
+			start := time.Now()
+			toolResp, err := getToolResp(ctx, tool)
+			if err != nil {
+				opts.notifyToolEvent(toolEvent{kind: toolFailed, id: callID, call: tool, err: err, duration: time.Since(start)})
+
+				// Abort on transport failures, but feed tool errors back to the model so it can recover
+				var toolErr *mcp.ToolError
+				if !errors.As(err, &toolErr) {
+					return nil, err
+				}
+
+				toolResp = "Error: " + toolErr.Message
+			} else {
+				opts.notifyToolEvent(toolEvent{kind: toolFinished, id: callID, call: tool, result: toolResp, duration: time.Since(start)})
+			}
+
 			*request.Messages = append(*request.Messages, ollama.Message{
-				Role:    ollama.ToolRole,
-				Content: toolResp,
+				Role:     ollama.ToolRole,
+				Content:  toolResp,
+				ToolName: tool.Function.Name,
 			})
 		}
-
-		return chatWithTools(ctx, request)
 	}
 
-	return response, nil
+	return finalAnswer(ctx, request, opts)
+}
+
+// finalAnswer asks the model to answer with the information gathered so far, without offering any tools.
+// The instruction is only sent along with this request and is not recorded in the conversation history.
+func finalAnswer(ctx context.Context, request ollama.ModelRequest, opts agentOptions) (*ollama.ModelResponse, error) {
+	messages := append(slices.Clone(*request.Messages), ollama.Message{
+		Role:    ollama.UserRole,
+		Content: finalAnswerPrompt,
+	})
+
+	request.Messages = &messages
+	request.Tools = nil
+
+	return ollama.ChatStream(ctx, OclaiConfig.BaseURL, request, opts.onChunk)
 }
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
 	"time"
@@ -16,8 +17,13 @@ import (
 	"github.com/thejasmeetsingh/oclai/pkg/utils"
 )
 
-// fileContents stores the content of files that need to be analyzed
-var fileContents []string
+var (
+	// fileContents stores the content of files that need to be analyzed
+	fileContents []string
+
+	// maxSteps overrides the configured tool-calling step limit for a query
+	maxSteps int
+)
 
 var (
 	// Chat command starts an interactive chat session with the specified model
@@ -30,10 +36,37 @@ var (
 		oclai chat
 		oclai ch
 		oclai chat --model gemma3:latest
+		oclai chat --persona reviewer
+		oclai chat --continue
+		oclai chat --resume 20250101-093000
 	`,
+		PersistentPreRunE: prepareRun,
 		Run: func(cmd *cobra.Command, args []string) {
 			// Use the default model if not specified
 			model := OclaiConfig.DefaultModel
+			messages := []ollama.Message{ollama.SystemPromptMessage(OclaiConfig.SystemPrompt)}
+
+			// Load the saved session to resume, if any
+			record, err := getResumedSession(cmd)
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(err.Error()))
+				os.Exit(1)
+			}
+
+			if record != nil {
+				// The model and the system prompt of the session are kept, unless overridden by the flags
+				if !cmd.Flags().Changed("model") && record.Model != "" {
+					model = record.Model
+				}
+
+				messages = record.Messages
+				if _, flag, _ := getSystemPromptFlag(cmd); flag != "" {
+					if len(messages) != 0 && messages[0].Role == ollama.SystemRole {
+						messages = messages[1:]
+					}
+					messages = append([]ollama.Message{ollama.SystemPromptMessage(OclaiConfig.SystemPrompt)}, messages...)
+				}
+			}
 
 			// List available models
 			models, err := ollama.ListModels(OclaiConfig.BaseURL)
@@ -82,19 +115,22 @@ var (
 			modelRequest := ollama.ModelRequest{
 				Model:    model,
 				Think:    false,
-				Messages: &[]ollama.Message{ollama.SystemPromptMessage()},
+				Messages: &messages,
 				Tools:    mcp.GetAllTools(),
 			}
 
 			// Initialize the chat session with the model
 			program := tea.NewProgram(
-				initSession(modelRequest, models),
+				initSession(modelRequest, models, record),
 				tea.WithAltScreen(),
 				tea.WithMouseCellMotion(),
 			)
 
-			// Run the chat session
-			if _, err := program.Run(); err != nil {
+			// Run the chat session, shutting down the MCP servers started during the session afterwards
+			_, err = program.Run()
+			mcp.CloseSessions()
+
+			if err != nil {
 				fmt.Println(utils.ErrorMessage(err.Error()))
 				os.Exit(1)
 			}
@@ -114,7 +150,11 @@ var (
 		oclai query "Hey what's up" --model qwen3:latest
 		cat /path/file.txt | oclai q "Summerize this file"
 		oclai q "Analyze this code" -f /path/main.py
+		oclai q "List the go files in this directory" --max-steps 5
+		oclai q "Review this code" -f main.go --system "You are a strict Go reviewer"
+		oclai q "Tidy up the notes directory" --yes
 	`,
+		PersistentPreRunE: prepareRun,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			// Check if a default model is selected
 			if OclaiConfig.DefaultModel == "" {
@@ -152,38 +192,61 @@ var (
 			request := ollama.ModelRequest{
 				Model: OclaiConfig.DefaultModel,
 				Think: false,
-				Messages: &[]ollama.Message{{
-					Role:    ollama.UserRole,
-					Content: query,
-				}},
+				Messages: &[]ollama.Message{
+					ollama.SystemPromptMessage(OclaiConfig.SystemPrompt),
+					{
+						Role:    ollama.UserRole,
+						Content: query,
+					},
+				},
 				Tools: mcp.GetAllTools(),
 			}
 
-			// Get the model response
-			modelResponse, err := chatWithTools(context.Background(), request)
-			if err != nil {
-				fmt.Println(utils.ErrorMessage(err.Error()))
-				os.Exit(1)
+			// Cancel the request on interrupt
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+
+			// Use the configured step limit unless overridden
+			if maxSteps <= 0 {
+				maxSteps = OclaiConfig.MaxSteps
 			}
 
-			// Convert the response to markdown format
-			result, err := utils.ToMarkDown(modelResponse.Message.Content)
+			// Print the tokens as they arrive, the answer is rendered as markdown once complete
+			printer := newAnswerPrinter()
+			approve := getQueryApprover(cmd)
+
+			// Get the model response
+			modelResponse, err := chatWithTools(ctx, request, agentOptions{
+				maxSteps: maxSteps,
+				approve: func(ctx context.Context, approval toolApproval) (approvalDecision, error) {
+					printer.flush()
+					return approve(ctx, approval)
+				},
+				approvedTools: make(map[string]bool),
+				onChunk: func(message ollama.Message) {
+					printer.write(message.Content)
+				},
+				onToolEvent: printer.toolEvent,
+			})
+
+			// Shut down the MCP servers started for the query
+			mcp.CloseSessions()
+
+			// Terminate the streamed output
+			printer.flush()
+
 			if err != nil {
 				fmt.Println(utils.ErrorMessage(err.Error()))
 				os.Exit(1)
 			}
 
-			// Add performance statistic
-			if modelResponse.TotalDuration > 0 {
+			// Add performance statistic, unless the output is piped to another program or a file
+			if modelResponse.TotalDuration > 0 && utils.IsTerminal(os.Stdout) {
 				duration := time.Duration(modelResponse.TotalDuration)
 				tokensPerSec := float64(modelResponse.EvalCount) / duration.Seconds()
-				stat := utils.SuccessBox(fmt.Sprintf("✓ Generated %d tokens in %v (%.1f tokens/sec)",
-					modelResponse.EvalCount, duration, tokensPerSec))
-
-				result = fmt.Sprintf("%s\n%s", result, stat)
+				fmt.Println(utils.SuccessBox(fmt.Sprintf("✓ Generated %d tokens in %v (%.1f tokens/sec)",
+					modelResponse.EvalCount, duration, tokensPerSec)))
 			}
-
-			fmt.Println(result)
 		},
 	}
 )
@@ -199,4 +262,60 @@ func init() {
 		fileContents = contents
 		return nil
 	})
+
+	// Register the flags to override the configuration for a single run
+	addOverrideFlags(Chat)
+	addOverrideFlags(Query)
+
+	// Register the flags to resume a saved chat session
+	Chat.Flags().String("resume", "", "Resume the saved chat session with the given ID")
+	Chat.Flags().Bool("continue", false, "Resume the last saved chat session")
+	Chat.MarkFlagsMutuallyExclusive("resume", "continue")
+
+	// Register the max steps flag to override the tool-calling step limit
+	Query.PersistentFlags().IntVar(&maxSteps, "max-steps", 0, "Maximum number of tool-calling steps (defaults to the configured limit)")
+
+	// Register the flags to answer the tool approvals of non-interactive runs
+	Query.Flags().BoolP("yes", "y", false, "Approve the tool calls which require an approval")
+	Query.Flags().Bool("deny-tools", false, "Deny the tool calls which require an approval")
+	Query.MarkFlagsMutuallyExclusive("yes", "deny-tools")
+}
+
+// getQueryApprover returns how the tool calls requiring an approval are answered in a query:
+// by the flags, or on the terminal if the input is not piped
+func getQueryApprover(cmd *cobra.Command) func(context.Context, toolApproval) (approvalDecision, error) {
+	if yes, _ := cmd.Flags().GetBool("yes"); yes {
+		return func(context.Context, toolApproval) (approvalDecision, error) {
+			return approvalDecision{allowed: true}, nil
+		}
+	}
+
+	if deny, _ := cmd.Flags().GetBool("deny-tools"); deny {
+		return func(context.Context, toolApproval) (approvalDecision, error) {
+			return approvalDecision{reason: "the tool calls requiring an approval are denied for this run"}, nil
+		}
+	}
+
+	// The approval cannot be asked for if the input is piped
+	if !utils.IsTerminal(os.Stdin) {
+		return func(context.Context, toolApproval) (approvalDecision, error) {
+			return approvalDecision{reason: "the tool requires an approval, run the query with '--yes' to approve it"}, nil
+		}
+	}
+
+	return newTerminalApprover(os.Stdin, os.Stderr).ask
+}
+
+// getResumedSession returns the saved session selected by the resume flags, if any
+func getResumedSession(cmd *cobra.Command) (*chatSession, error) {
+	if cmd.Flags().Changed("resume") {
+		id, _ := cmd.Flags().GetString("resume")
+		return loadChatSession(id)
+	}
+
+	if resume, _ := cmd.Flags().GetBool("continue"); resume {
+		return lastChatSession()
+	}
+
+	return nil, nil
 }
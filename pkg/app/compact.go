@@ -0,0 +1,140 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/thejasmeetsingh/oclai/pkg/ollama"
+)
+
+const (
+	// compactThreshold is the share of the context limit above which the conversation is compacted
+	compactThreshold = 0.8
+
+	// compactKeepTurns is the number of most recent turns kept verbatim when compacting
+	compactKeepTurns = 2
+
+	// maxSummaryToolOutput is the maximum length of a tool result included in the summarization request
+	maxSummaryToolOutput = 2000
+
+	// summaryPrefix marks the message holding the summary of the compacted turns
+	summaryPrefix = "[Summary of the earlier conversation]\n"
+)
+
+// summarizePrompt asks the model to summarize the oldest turns of the conversation
+const summarizePrompt = "Summarize the following conversation between a user and an AI assistant. " +
+	"Keep the facts, decisions, file names, code snippets, tool results and open questions which may be needed to continue the conversation. " +
+	"Answer with the summary only."
+
+// isContextNearLimit reports whether the given token count is close enough to the context limit to compact the conversation
+func isContextNearLimit(tokens int) bool {
+	return OclaiConfig.NumCtx > 0 && float64(tokens) >= compactThreshold*float64(OclaiConfig.NumCtx)
+}
+
+// estimateTokens roughly estimates the token count of the given messages, at about four characters per token.
+// It is used until the model reports the actual count.
+func estimateTokens(messages []ollama.Message) int {
+	chars := 0
+	for _, message := range messages {
+		chars += len(message.Content)
+		for _, tool := range message.ToolCalls {
+			chars += len(toolCallKey(tool))
+		}
+	}
+
+	return chars / 4
+}
+
+// getCompactionIndex returns the index of the first message to keep verbatim, i.e. the start
+// of the most recent turns. The messages before it, except the system prompt, are summarized.
+func getCompactionIndex(messages []ollama.Message, keepTurns int) int {
+	turns := 0
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == ollama.UserRole && !strings.HasPrefix(messages[i].Content, summaryPrefix) {
+			turns++
+			if turns == keepTurns {
+				return i
+			}
+		}
+	}
+
+	return 0
+}
+
+// formatForSummary renders the given messages as a plain transcript for the summarization request
+func formatForSummary(messages []ollama.Message) string {
+	var transcript strings.Builder
+
+	for _, message := range messages {
+		switch message.Role {
+		case ollama.UserRole:
+			fmt.Fprintf(&transcript, "User: %s\n\n", message.Content)
+		case ollama.AssistantRole:
+			for _, tool := range message.ToolCalls {
+				fmt.Fprintf(&transcript, "Assistant called tool: %s\n\n", toolCallKey(tool))
+			}
+			if message.Content != "" {
+				fmt.Fprintf(&transcript, "Assistant: %s\n\n", message.Content)
+			}
+		case ollama.ToolRole:
+			content := message.Content
+			if len(content) > maxSummaryToolOutput {
+				content = content[:maxSummaryToolOutput] + "… (truncated)"
+			}
+			fmt.Fprintf(&transcript, "Tool result (%s): %s\n\n", message.ToolName, content)
+		}
+	}
+
+	return transcript.String()
+}
+
+// getCompactionRange returns the range of the messages to summarize, which is empty
+// if the conversation is too short to be compacted
+func getCompactionRange(messages []ollama.Message) (int, int) {
+	start := 0
+	if len(messages) != 0 && messages[0].Role == ollama.SystemRole {
+		start = 1
+	}
+
+	end := getCompactionIndex(messages, compactKeepTurns)
+	if end <= start+1 {
+		return start, start
+	}
+
+	return start, end
+}
+
+// compactMessages summarizes the oldest turns of the conversation with the given model.
+// The system prompt and the most recent turns are kept as they are. It returns the compacted
+// conversation along with the number of messages which were summarized.
+func compactMessages(ctx context.Context, model string, messages []ollama.Message) ([]ollama.Message, int, error) {
+	start, end := getCompactionRange(messages)
+	if start == end {
+		return nil, 0, fmt.Errorf("the conversation is too short to be compacted")
+	}
+
+	request := ollama.ModelRequest{
+		Model: model,
+		Think: false,
+		Messages: &[]ollama.Message{
+			{Role: ollama.SystemRole, Content: summarizePrompt},
+			{Role: ollama.UserRole, Content: formatForSummary(messages[start:end])},
+		},
+		Options: map[string]any{"num_ctx": OclaiConfig.NumCtx},
+	}
+
+	response, err := ollama.Chat(ctx, OclaiConfig.BaseURL, request)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to summarize the conversation: %s", err.Error())
+	}
+
+	compacted := append([]ollama.Message{}, messages[:start]...)
+	compacted = append(compacted, ollama.Message{
+		Role:    ollama.UserRole,
+		Content: summaryPrefix + strings.TrimSpace(response.Message.Content),
+	})
+	compacted = append(compacted, messages[end:]...)
+
+	return compacted, end - start, nil
+}
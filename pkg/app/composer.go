@@ -0,0 +1,156 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/thejasmeetsingh/oclai/pkg/utils"
+)
+
+const (
+	// maxComposerHeight is the maximum height of the message composer in lines, longer drafts are scrolled
+	maxComposerHeight = 8
+
+	// composerPlaceholder is shown while the draft is empty
+	composerPlaceholder = "Type your message here... (try typing '/' for commands)"
+)
+
+// editorFinishedMsg is sent once the user's editor, opened on the draft, exits
+type editorFinishedMsg struct {
+	filePath string
+	err      error
+}
+
+// newComposer creates the multiline message composer.
+// Enter sends the message, while Shift+Enter, Alt+Enter or Ctrl+J insert a new line.
+func newComposer() textarea.Model {
+	ta := textarea.New()
+	ta.Placeholder = composerPlaceholder
+	ta.ShowLineNumbers = false
+	ta.CharLimit = 0
+	ta.MaxHeight = 0
+	ta.FocusedStyle.CursorLine = lipgloss.NewStyle()
+	ta.KeyMap.InsertNewline = key.NewBinding(
+		key.WithKeys("shift+enter", "alt+enter", "ctrl+j"),
+		key.WithHelp("shift+enter", "insert newline"),
+	)
+
+	// Show the prompt on the first line only, the following lines are aligned with it
+	ta.SetPromptFunc(lipgloss.Width(userPromptText()), func(lineIdx int) string {
+		if lineIdx == 0 {
+			return userPromptText()
+		}
+		return ""
+	})
+
+	ta.SetWidth(defaultWidth)
+	ta.SetHeight(1)
+	ta.Focus()
+
+	return ta
+}
+
+// resizeComposer grows the composer along with the draft, up to its maximum height
+func (s *session) resizeComposer() {
+	s.textInput.SetHeight(min(max(s.textInput.LineCount(), 1), maxComposerHeight))
+}
+
+// completeSuggestion completes the draft with the first suggested command.
+// It reports whether there was a suggestion to complete.
+func (s *session) completeSuggestion() bool {
+	if len(s.suggestions) == 0 {
+		return false
+	}
+
+	s.textInput.SetValue(s.suggestions[0])
+	s.updateSuggestions()
+
+	return true
+}
+
+// suggestionsView returns the hint listing the commands matching the draft, if any
+func (s *session) suggestionsView() string {
+	if len(s.suggestions) == 0 {
+		return ""
+	}
+
+	return "\n" + utils.OtherMessage("Tab ↹ "+strings.Join(s.suggestions, "  "))
+}
+
+// openEditor opens the draft in the user's editor, the edited draft replaces the composer content
+func (s *session) openEditor() tea.Cmd {
+	file, err := os.CreateTemp("", "oclai-*.md")
+	if err != nil {
+		return func() tea.Msg {
+			return editorFinishedMsg{err: err}
+		}
+	}
+
+	_, err = file.WriteString(s.textInput.Value())
+	file.Close()
+	if err != nil {
+		return func() tea.Msg {
+			return editorFinishedMsg{filePath: file.Name(), err: err}
+		}
+	}
+
+	return tea.ExecProcess(utils.GetEditorCmd(file.Name()), func(err error) tea.Msg {
+		return editorFinishedMsg{filePath: file.Name(), err: err}
+	})
+}
+
+// handleEditorFinished loads the draft edited in the user's editor into the composer
+func (s *session) handleEditorFinished(msg editorFinishedMsg) {
+	if msg.filePath != "" {
+		defer os.Remove(msg.filePath)
+	}
+
+	if msg.err != nil {
+		s.updateSessionMessages(sessionMessage{
+			_type:   errMsg,
+			content: fmt.Sprintf("Failed to edit the message: %s", msg.err.Error()),
+		})
+		return
+	}
+
+	data, err := os.ReadFile(msg.filePath)
+	if err != nil {
+		s.updateSessionMessages(sessionMessage{
+			_type:   errMsg,
+			content: fmt.Sprintf("Failed to read the edited message: %s", err.Error()),
+		})
+		return
+	}
+
+	s.textInput.SetValue(strings.TrimRight(string(data), "\n"))
+	s.resizeComposer()
+	s.updateSuggestions()
+}
+
+// updateSuggestions updates the command suggestions based on the current draft
+func (s *session) updateSuggestions() {
+	input := strings.ToLower(s.textInput.Value())
+
+	// Only show suggestions if the draft is a single line starting with a slash
+	if !strings.HasPrefix(input, "/") || len(input) <= 1 || strings.ContainsAny(input, " \n") {
+		s.suggestions = nil
+		return
+	}
+
+	// Find matching commands
+	var matches []string
+	for cmd := range subcommands {
+		if strings.HasPrefix(cmd, input) && cmd != input {
+			matches = append(matches, cmd)
+		}
+	}
+	slices.Sort(matches)
+
+	s.suggestions = matches
+}
@@ -2,28 +2,169 @@ package app
 
 import (
 	"encoding/json"
+	"fmt"
+	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
+	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/thejasmeetsingh/oclai/pkg/mcp"
 	"github.com/thejasmeetsingh/oclai/pkg/utils"
 )
 
-// AppConfigFileName is the name of the configuration file
-const AppConfigFileName = "config"
+const (
+	// AppConfigFileName is the name of the configuration file
+	AppConfigFileName = "config"
 
-// Config represents the application configuration structure
-type Config struct {
-	BaseURL      string `json:"baseURL"`      // Base URL for API endpoints
-	DefaultModel string `json:"defaultModel"` // Default model to use
-	NumCtx       int    `json:"numCtx"`       // Maximum context length
-	InitMCP      bool   `json:"initMCP"`      // Whether to initialize MCP
+	// defaultMaxSteps is the default number of tool-calling steps allowed per request
+	defaultMaxSteps = 10
+
+	// configVersion is the current schema version of the configuration file
+	configVersion = 2
+
+	// defaultProfileName is the name of the profile created along with the configuration
+	defaultProfileName = "default"
+)
+
+type (
+	// Profile represents a named set of settings, e.g. for a specific Ollama host
+	Profile struct {
+		BaseURL      string   `json:"baseURL"`                // Base URL for API endpoints
+		DefaultModel string   `json:"defaultModel"`           // Default model to use
+		NumCtx       int      `json:"numCtx"`                 // Maximum context length
+		MaxSteps     int      `json:"maxSteps"`               // Maximum tool-calling steps per request
+		SystemPrompt string   `json:"systemPrompt,omitempty"` // System prompt of the chat sessions
+		McpServers   []string `json:"mcpServers,omitempty"`   // Enabled MCP servers, all of them if empty
+
+		// ToolPermissions holds the permission rules of the tool calls, as "server/tool=policy" glob patterns.
+		// The first matching rule applies, and the calls matching no rule require an approval.
+		ToolPermissions []string `json:"toolPermissions,omitempty"`
+	}
+
+	// Config represents the application configuration structure
+	Config struct {
+		Version       int                 `json:"version"`       // Schema version of the configuration
+		InitMCP       bool                `json:"initMCP"`       // Whether to initialize MCP
+		ActiveProfile string              `json:"activeProfile"` // Profile used when none is selected
+		Profiles      map[string]*Profile `json:"profiles"`      // Named profiles
+
+		// TrustedProjects holds the directories of the trusted projects, whose configuration
+		// can start MCP servers, set tool permissions or change the Ollama BaseURL
+		TrustedProjects []string `json:"trustedProjects,omitempty"`
+	}
+)
+
+var (
+	// OclaiConfig holds the effective settings for the current run,
+	// i.e. the selected profile with the environment and flag overrides applied
+	OclaiConfig Profile
+
+	// userConfig holds the configuration as stored in the user configuration file
+	userConfig Config
+
+	// selectedProfile is the name of the profile used for the current run
+	selectedProfile = ""
+
+	// projectConfigPath is the path of the project-local configuration, if any
+	projectConfigPath = ""
+
+	// projectTrusted tells whether the project-local configuration is trusted
+	projectTrusted = false
+
+	// projectSettings holds the settings of the project-local configuration, by configuration key
+	projectSettings = make(map[string]string)
+
+	// configOrigins tracks where each effective setting comes from, by configuration key
+	configOrigins = make(map[string]string)
+
+	// rootPath stores the application root directory path, as given when loading the configuration
+	rootPath = ""
+)
+
+// configMigrations holds the migrations of the configuration file, the migration at index i
+// upgrades a configuration from schema version i to version i+1.
+// The keys of the raw configuration are lowercased, as viper writes them in lowercase.
+var configMigrations = []func(config map[string]any){
+	// Version 1: Introduce the step limit of the agent loop
+	func(config map[string]any) {
+		if maxSteps, ok := config["maxsteps"].(float64); !ok || maxSteps <= 0 {
+			config["maxsteps"] = defaultMaxSteps
+		}
+	},
+
+	// Version 2: Move the settings into the default profile
+	func(config map[string]any) {
+		profile := make(map[string]any)
+		for _, key := range []string{"baseurl", "defaultmodel", "numctx", "maxsteps"} {
+			if value, ok := config[key]; ok {
+				profile[key] = value
+				delete(config, key)
+			}
+		}
+
+		config["profiles"] = map[string]any{defaultProfileName: profile}
+		config["activeprofile"] = defaultProfileName
+	},
+}
+
+// defaultProfile returns the settings used when no value is set by the user
+func defaultProfile() Profile {
+	return Profile{
+		BaseURL:      "http://localhost:11434",
+		DefaultModel: "",
+		NumCtx:       8000,
+		MaxSteps:     defaultMaxSteps,
+	}
 }
 
-// OclaiConfig holds the loaded configuration for the application
-var OclaiConfig Config
+// migrateConfig applies the pending migrations to the given raw configuration.
+// It reports whether the configuration was migrated.
+func migrateConfig(config map[string]any) (bool, error) {
+	// Normalize the top-level keys, the JSON decoding of the config is case-insensitive anyway
+	for key, value := range config {
+		if lowerKey := strings.ToLower(key); lowerKey != key {
+			delete(config, key)
+			config[lowerKey] = value
+		}
+	}
+
+	version := 0
+	if value, ok := config["version"].(float64); ok {
+		version = int(value)
+	}
+
+	if version > configVersion {
+		return false, fmt.Errorf("configuration version %d is not supported by this version of oclai, please upgrade", version)
+	}
+
+	migrated := version < configVersion
+
+	for ; version < configVersion; version++ {
+		configMigrations[version](config)
+		config["version"] = version + 1
+	}
+
+	return migrated, nil
+}
+
+// envOverrides maps the environment variables to the configuration keys they override
+var envOverrides = []struct {
+	env string
+	key string
+}{
+	{env: "OCLAI_BASE_URL", key: "baseURL"},
+	{env: "OCLAI_MODEL", key: "defaultModel"},
+	{env: "OCLAI_NUM_CTX", key: "numCtx"},
+}
+
+// LoadConfig initializes and loads the application configuration.
+// The settings are taken from the given profile, the OCLAI_PROFILE environment variable,
+// or the active profile, in this order of precedence.
+func LoadConfig(appRootPath, profile string) error {
+	rootPath = appRootPath
 
-// LoadConfig initializes and loads the application configuration
-func LoadConfig(rootPath string) error {
 	// Construct the full path to the configuration file
 	filePath := filepath.Join(rootPath, AppConfigFileName)
 
@@ -37,10 +178,15 @@ func LoadConfig(rootPath string) error {
 	// Add the root path as a configuration search path
 	v.AddConfigPath(rootPath)
 
-	v.SetDefault("baseURL", "http://localhost:11434")
-	v.SetDefault("defaultModel", "")
-	v.SetDefault("numCtx", 8000)
+	defaults := defaultProfile()
+	profileKey := "profiles." + defaultProfileName
+	v.SetDefault("version", configVersion)
 	v.SetDefault("initMCP", true)
+	v.SetDefault("activeProfile", defaultProfileName)
+	v.SetDefault(profileKey+".baseURL", defaults.BaseURL)
+	v.SetDefault(profileKey+".defaultModel", defaults.DefaultModel)
+	v.SetDefault(profileKey+".numCtx", defaults.NumCtx)
+	v.SetDefault(profileKey+".maxSteps", defaults.MaxSteps)
 
 	// Write the configuration to the file (safe write to avoid overwriting)
 	v.SafeWriteConfigAs(filePath)
@@ -54,21 +200,201 @@ func LoadConfig(rootPath string) error {
 		return err
 	}
 
-	// Unmarshal the JSON data into the OclaiConfig struct
-	return json.Unmarshal(data, &OclaiConfig)
+	// Upgrade configuration files created by older versions
+	var rawConfig map[string]any
+	if err = json.Unmarshal(data, &rawConfig); err != nil {
+		return err
+	}
+
+	migrated, err := migrateConfig(rawConfig)
+	if err != nil {
+		return err
+	}
+
+	if data, err = json.Marshal(rawConfig); err != nil {
+		return err
+	}
+
+	// Unmarshal the JSON data into the userConfig struct
+	if err = json.Unmarshal(data, &userConfig); err != nil {
+		return err
+	}
+
+	// Select the profile of the run
+	selectedProfile = profile
+	if selectedProfile == "" {
+		selectedProfile = os.Getenv("OCLAI_PROFILE")
+	}
+	if selectedProfile == "" {
+		selectedProfile = userConfig.ActiveProfile
+	}
+
+	// Load the project-local configuration, which takes precedence over the user configuration
+	if err = loadProjectConfig(); err != nil {
+		return err
+	}
+
+	// Persist the migrated configuration
+	if migrated {
+		return UpdateConfig(rootPath, func(config *Config) {})
+	}
+
+	return resolveConfig()
 }
 
-// UpdateConfig updates the application configuration file with the current settings
-func UpdateConfig(rootPath string) error {
+// loadProjectConfig looks for a project-local configuration from the working directory and loads its settings
+func loadProjectConfig() error {
+	projectPath, err := utils.FindProjectConfig()
+	if err != nil || projectPath == "" {
+		return err
+	}
+
+	projectConfigPath = projectPath
+	projectTrusted = isTrustedProject(projectPath)
+
+	data, err := utils.ReadProjectConfig(projectPath, AppConfigFileName)
+	if err != nil || data == nil {
+		return err
+	}
+
+	var rawSettings map[string]any
+	if err = json.Unmarshal(data, &rawSettings); err != nil {
+		return fmt.Errorf("failed to parse the project configuration '%s': %s", projectPath, err)
+	}
+
+	ignoredKeys := make([]string, 0)
+
+	for rawKey, rawValue := range rawSettings {
+		// Match the keys case-insensitively, like the user configuration
+		key := ""
+		for name := range configKeys {
+			if strings.EqualFold(name, rawKey) {
+				key = name
+			}
+		}
+
+		if key == "" {
+			return fmt.Errorf("unknown key '%s' in the project configuration '%s'", rawKey, projectPath)
+		}
+
+		// Lists are set as comma separated values
+		value := fmt.Sprint(rawValue)
+		if list, ok := rawValue.([]any); ok {
+			values := make([]string, 0, len(list))
+			for _, el := range list {
+				values = append(values, fmt.Sprint(el))
+			}
+			value = strings.Join(values, ",")
+		}
+
+		// The sensitive settings of an untrusted project are ignored
+		if !projectTrusted && slices.Contains(trustedProjectKeys, key) {
+			ignoredKeys = append(ignoredKeys, key)
+			continue
+		}
+
+		projectSettings[key] = value
+	}
+
+	if len(ignoredKeys) != 0 {
+		slices.Sort(ignoredKeys)
+		fmt.Fprintln(os.Stderr, utils.ErrorMessage(fmt.Sprintf("Ignoring %s of the untrusted project '%s', run 'oclai trust' to apply them",
+			strings.Join(ignoredKeys, ", "), filepath.Dir(projectPath))))
+	}
+
+	return nil
+}
+
+// resolveConfig computes the effective settings from the selected profile,
+// the project-local configuration and the environment variables, in this order of precedence
+func resolveConfig() error {
+	profile, exists := userConfig.Profiles[selectedProfile]
+	if !exists {
+		return fmt.Errorf("'%s' profile does not exists", selectedProfile)
+	}
+
+	config := *profile
+
+	for name := range configKeys {
+		configOrigins[name] = fmt.Sprintf("user (profile '%s')", selectedProfile)
+	}
+
+	for key, value := range projectSettings {
+		if err := configKeys[key].set(&config, value); err != nil {
+			return fmt.Errorf("invalid '%s' value in the project configuration '%s': %s", key, projectConfigPath, err.Error())
+		}
+		configOrigins[key] = fmt.Sprintf("project (%s)", projectConfigPath)
+	}
+
+	for _, override := range envOverrides {
+		value, ok := os.LookupEnv(override.env)
+		if !ok || value == "" {
+			continue
+		}
+
+		if err := configKeys[override.key].set(&config, value); err != nil {
+			return fmt.Errorf("invalid value of '%s' environment variable: %s", override.env, err.Error())
+		}
+		configOrigins[override.key] = fmt.Sprintf("env (%s)", override.env)
+	}
+
+	OclaiConfig = config
+	return nil
+}
+
+// InitializeMCP discovers the tools of the MCP servers if it is required by the configuration.
+// Only the servers in scope for the run are started, so the server selection has to be applied first.
+func InitializeMCP(cmd *cobra.Command, args []string) error {
+	if !userConfig.InitMCP {
+		// The tools of the project servers are cached apart, so the new ones are discovered
+		if err := mcp.InitializeProjectServers(cmd.Context()); err != nil {
+			return fmt.Errorf("failed to initialize the project MCP servers: %s", err.Error())
+		}
+		return nil
+	}
+
+	// Servers which fail to start are reported and disabled for this run, so this only fails on config errors
+	complete, err := mcp.InitializeServers(cmd.Context(), rootPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize MCP servers: %s", err.Error())
+	}
+
+	// Keep initializing on the next runs until the servers left out of this one are discovered
+	if !complete {
+		return nil
+	}
+
+	// Disable MCP initialization after the initialization, and update the configuration file
+	return UpdateConfig(rootPath, func(config *Config) {
+		config.InitMCP = false
+	})
+}
+
+// UpdateConfig applies the given changes to the user configuration and writes it to the configuration file.
+// The effective configuration is then recomputed, so the overrides still take precedence.
+func UpdateConfig(rootPath string, update func(*Config)) error {
 	// Construct the full path to the configuration file
 	filePath := filepath.Join(rootPath, AppConfigFileName)
 
-	// Marshal the current configuration into JSON format
-	data, err := json.MarshalIndent(&OclaiConfig, "", "  ")
+	update(&userConfig)
+
+	// Marshal the user configuration into JSON format
+	data, err := json.MarshalIndent(&userConfig, "", "  ")
 	if err != nil {
 		return err
 	}
 
 	// Write the JSON data to the configuration file
-	return utils.WriteFileContents(filePath, data)
+	if err = utils.WriteFileContents(filePath, data); err != nil {
+		return err
+	}
+
+	return resolveConfig()
+}
+
+// updateProfile applies the given changes to the selected profile and writes the configuration file.
+func updateProfile(update func(*Profile)) error {
+	return UpdateConfig(rootPath, func(config *Config) {
+		update(config.Profiles[selectedProfile])
+	})
 }
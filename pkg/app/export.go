@@ -0,0 +1,134 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/thejasmeetsingh/oclai/pkg/ollama"
+	"github.com/thejasmeetsingh/oclai/pkg/utils"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// exportFormats are the supported transcript formats, the first one being the default
+var exportFormats = []string{"md", "json", "html"}
+
+// transcriptTemplate is the HTML document wrapping the rendered transcript
+var transcriptTemplate = template.Must(template.New("transcript").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; max-width: 860px; margin: 2rem auto; padding: 0 1rem; line-height: 1.6; color: #1f2328; }
+pre { background: #f6f8fa; padding: 1rem; overflow-x: auto; border-radius: 6px; }
+code { font-family: ui-monospace, SFMono-Regular, Menlo, monospace; font-size: 0.9em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #d0d7de; padding: 0.3rem 0.8rem; }
+hr { border: 0; border-top: 1px solid #d0d7de; margin: 2rem 0; }
+</style>
+</head>
+<body>
+{{.Body}}
+</body>
+</html>
+`))
+
+// getExportFormat returns the transcript format matching the extension of the given path,
+// or the default format if the extension is not supported
+func getExportFormat(filePath string) string {
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(filePath)), ".")
+	if format == "markdown" {
+		format = "md"
+	}
+
+	if !slices.Contains(exportFormats, format) {
+		return exportFormats[0]
+	}
+
+	return format
+}
+
+// exportTranscript renders the transcript of the given session in the given format
+func exportTranscript(c *chatSession, format string) ([]byte, error) {
+	switch format {
+	case "md":
+		return []byte(transcriptMarkdown(c)), nil
+	case "json":
+		return json.MarshalIndent(c, "", "  ")
+	case "html":
+		return transcriptHTML(c)
+	default:
+		return nil, fmt.Errorf("invalid format '%s', should be one of: %s", format, strings.Join(exportFormats, ", "))
+	}
+}
+
+// transcriptMarkdown renders the transcript of the given session as markdown,
+// including the tool calls and their results
+func transcriptMarkdown(c *chatSession) string {
+	var md strings.Builder
+
+	fmt.Fprintf(&md, "# %s\n\n", c.displayTitle())
+	fmt.Fprintf(&md, "- **ID:** %s\n- **Model:** %s\n- **Started:** %s\n- **Updated:** %s\n",
+		c.ID, c.Model, c.CreatedAt.Format(time.DateTime), c.UpdatedAt.Format(time.DateTime))
+
+	if len(c.ToolsUsed) != 0 {
+		fmt.Fprintf(&md, "- **Tools used:** %s\n", strings.Join(c.ToolsUsed, ", "))
+	}
+
+	for _, message := range c.Messages {
+		switch message.Role {
+		case ollama.SystemRole:
+			fmt.Fprintf(&md, "\n---\n\n## 🧭 System\n\n%s\n", message.Content)
+
+		case ollama.UserRole:
+			fmt.Fprintf(&md, "\n---\n\n## 👤 You\n\n%s\n", message.Content)
+
+		case ollama.AssistantRole:
+			fmt.Fprintf(&md, "\n---\n\n## 🤖 Assistant\n\n")
+			if message.Content != "" {
+				fmt.Fprintf(&md, "%s\n", message.Content)
+			}
+
+			for _, tool := range message.ToolCalls {
+				args, _ := json.MarshalIndent(tool.Function.Args, "", "  ")
+				fmt.Fprintf(&md, "\n**🔧 Tool call:** `%s`\n\n```json\n%s\n```\n", tool.Function.Name, args)
+			}
+
+		case ollama.ToolRole:
+			fmt.Fprintf(&md, "\n**📦 Tool result:** `%s`\n\n%s\n", message.ToolName, utils.CodeBlock(message.Content))
+		}
+	}
+
+	return md.String()
+}
+
+// transcriptHTML renders the transcript of the given session as a standalone HTML document
+func transcriptHTML(c *chatSession) ([]byte, error) {
+	var body bytes.Buffer
+
+	markdown := goldmark.New(goldmark.WithExtensions(extension.GFM))
+	if err := markdown.Convert([]byte(transcriptMarkdown(c)), &body); err != nil {
+		return nil, err
+	}
+
+	var document bytes.Buffer
+	err := transcriptTemplate.Execute(&document, struct {
+		Title string
+		Body  template.HTML
+	}{
+		Title: c.displayTitle(),
+		Body:  template.HTML(body.String()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return document.Bytes(), nil
+}
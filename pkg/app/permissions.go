@@ -0,0 +1,320 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/thejasmeetsingh/oclai/pkg/mcp"
+	"github.com/thejasmeetsingh/oclai/pkg/ollama"
+	"github.com/thejasmeetsingh/oclai/pkg/utils"
+)
+
+// toolPolicy decides whether a tool call is executed
+type toolPolicy string
+
+const (
+	policyAllow toolPolicy = "allow" // The call is executed
+	policyAsk   toolPolicy = "ask"   // The call is executed once approved by the user
+	policyDeny  toolPolicy = "deny"  // The call is rejected
+
+	// defaultToolPolicy applies to the tool calls matching no rule, so that no tool runs without
+	// the user's consent unless a rule allows it
+	defaultToolPolicy = policyAsk
+
+	// deniedToolPrefix starts the tool response of a denied call
+	deniedToolPrefix = "Error: the user denied this tool call"
+)
+
+type (
+	// toolRule applies a policy to the tools matching a "server/tool" glob pattern.
+	// A pattern without a slash matches all the tools of the servers it matches.
+	toolRule struct {
+		pattern string
+		policy  toolPolicy
+	}
+
+	// toolApproval is a tool call waiting for the user's approval
+	toolApproval struct {
+		server string
+		call   ollama.ToolCall
+	}
+
+	// approvalDecision is the user's answer to a tool approval
+	approvalDecision struct {
+		allowed bool
+		always  bool   // Approve the tool for the rest of the session
+		reason  string // Reason of the denial, fed back to the model
+	}
+)
+
+// parseToolRule parses a rule written as "pattern=policy", e.g. "filesystem/write_*=ask"
+func parseToolRule(value string) (toolRule, error) {
+	pattern, policy, found := strings.Cut(value, "=")
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	policy = strings.ToLower(strings.TrimSpace(policy))
+
+	if !found || pattern == "" {
+		return toolRule{}, fmt.Errorf("invalid rule '%s', expected 'server/tool=policy'", value)
+	}
+
+	if !strings.Contains(pattern, "/") {
+		pattern += "/*"
+	}
+
+	if _, err := path.Match(pattern, ""); err != nil {
+		return toolRule{}, fmt.Errorf("invalid pattern in rule '%s': %s", value, err.Error())
+	}
+
+	switch toolPolicy(policy) {
+	case policyAllow, policyAsk, policyDeny:
+		return toolRule{pattern: pattern, policy: toolPolicy(policy)}, nil
+	}
+
+	return toolRule{}, fmt.Errorf("invalid policy in rule '%s', expected allow, ask or deny", value)
+}
+
+// parseToolRules parses and validates the given rules
+func parseToolRules(values []string) ([]toolRule, error) {
+	rules := make([]toolRule, 0, len(values))
+	for _, value := range values {
+		rule, err := parseToolRule(value)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// getToolPolicy returns the policy of the first rule matching the given tool, case-insensitively.
+// The tool calls matching no rule require an approval.
+func getToolPolicy(values []string, server, tool string) toolPolicy {
+	name := strings.ToLower(server + "/" + tool)
+
+	for _, value := range values {
+		rule, err := parseToolRule(value)
+		if err != nil {
+			continue
+		}
+
+		if matched, _ := path.Match(rule.pattern, name); matched {
+			return rule.policy
+		}
+	}
+
+	return defaultToolPolicy
+}
+
+// authorizeToolCall applies the permission policy of the given tool call, asking the user
+// for approval if the policy requires it. The tools approved for the session are not asked again.
+func (opts agentOptions) authorizeToolCall(ctx context.Context, tool ollama.ToolCall) (approvalDecision, error) {
+	server := mcp.GetToolServerName(tool.Function.Name)
+
+	// Let the calls of unknown tools fail on their own
+	if server == "" {
+		return approvalDecision{allowed: true}, nil
+	}
+
+	switch getToolPolicy(OclaiConfig.ToolPermissions, server, tool.Function.Name) {
+	case policyAllow:
+		return approvalDecision{allowed: true}, nil
+	case policyDeny:
+		return approvalDecision{reason: "the tool is denied by the permission policy"}, nil
+	}
+
+	key := strings.ToLower(server + "/" + tool.Function.Name)
+	if opts.approvedTools[key] {
+		return approvalDecision{allowed: true}, nil
+	}
+
+	if opts.approve == nil {
+		return approvalDecision{reason: "the tool requires an approval which cannot be asked for in this run"}, nil
+	}
+
+	decision, err := opts.approve(ctx, toolApproval{server: server, call: tool})
+	if err != nil {
+		return decision, err
+	}
+
+	if decision.allowed && decision.always && opts.approvedTools != nil {
+		opts.approvedTools[key] = true
+	}
+
+	return decision, nil
+}
+
+// deniedToolResponse returns the tool response telling the model that the call was denied
+func deniedToolResponse(reason string) string {
+	if reason == "" {
+		return deniedToolPrefix
+	}
+
+	return deniedToolPrefix + ": " + reason
+}
+
+// describeToolCall describes the given tool call for an approval prompt
+func describeToolCall(approval toolApproval) string {
+	args, _ := json.Marshal(approval.call.Function.Args)
+	return fmt.Sprintf("'%s' from '%s' with %s", approval.call.Function.Name, approval.server, args)
+}
+
+// terminalApprover asks on the terminal whether the tool calls can be executed, for the whole run.
+// The prompts are written to the standard error, so they are not mixed with the piped output.
+type terminalApprover struct {
+	in    io.Reader
+	out   io.Writer
+	once  sync.Once
+	lines chan string // Lines of the input, closed at its end
+}
+
+// newTerminalApprover creates an approver reading the answers from the given input
+func newTerminalApprover(in io.Reader, out io.Writer) *terminalApprover {
+	return &terminalApprover{in: in, out: out}
+}
+
+// readLine reads a line from the input, unless the context is cancelled first.
+// A read cannot be interrupted, so the input is read by a single goroutine started on the first call:
+// the line of a prompt cancelled by the context is left to the next prompt instead of being lost.
+// The goroutine stays blocked on the input until the end of the program.
+func (a *terminalApprover) readLine(ctx context.Context) (string, error) {
+	a.once.Do(func() {
+		a.lines = make(chan string)
+		go func() {
+			defer close(a.lines)
+
+			reader := bufio.NewReader(a.in)
+			for {
+				text, err := reader.ReadString('\n')
+				if text != "" || err == nil {
+					a.lines <- strings.TrimSpace(text)
+				}
+				if err != nil {
+					return
+				}
+			}
+		}()
+	})
+
+	select {
+	case text := <-a.lines:
+		return text, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// ask asks whether the given tool call can be executed, denying it at the end of the input
+func (a *terminalApprover) ask(ctx context.Context, approval toolApproval) (approvalDecision, error) {
+	fmt.Fprintln(a.out, utils.OtherMessage("🔐 Allow tool "+describeToolCall(approval)+"? [y]es, [a]lways, [N]o:"))
+	answer, err := a.readLine(ctx)
+	if err != nil {
+		return approvalDecision{}, err
+	}
+
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return approvalDecision{allowed: true}, nil
+	case "a", "always":
+		return approvalDecision{allowed: true, always: true}, nil
+	}
+
+	fmt.Fprintln(a.out, utils.OtherMessage("Reason of the denial (optional):"))
+	reason, err := a.readLine(ctx)
+	if err != nil {
+		return approvalDecision{}, err
+	}
+
+	return approvalDecision{reason: reason}, nil
+}
+
+type (
+	// pendingApproval is a tool call waiting for the user's approval in the chat
+	pendingApproval struct {
+		approval toolApproval
+		reply    chan approvalDecision
+
+		// denying is set while the user writes the reason of the denial
+		denying bool
+	}
+
+	// approvalRequestMsg asks the user to approve a tool call during a chat request
+	approvalRequestMsg struct {
+		approval toolApproval
+		reply    chan approvalDecision
+	}
+)
+
+// denialPlaceholder is shown in the composer while the reason of a denial is empty
+const denialPlaceholder = "Reason of the denial, fed back to the model (optional)"
+
+// requestApproval asks the chat for the approval of the given tool call, and waits for the answer.
+// It is called by the agent loop, while the chat keeps handling the user input.
+func (s *session) requestApproval(ctx context.Context, approval toolApproval) (approvalDecision, error) {
+	reply := make(chan approvalDecision, 1)
+	s.events <- approvalRequestMsg{approval: approval, reply: reply}
+
+	select {
+	case decision := <-reply:
+		return decision, nil
+	case <-ctx.Done():
+		return approvalDecision{}, ctx.Err()
+	}
+}
+
+// answerApproval sends the given answer to the pending tool approval
+func (s *session) answerApproval(decision approvalDecision) {
+	s.approval.reply <- decision
+	s.approval = nil
+
+	s.textInput.Placeholder = composerPlaceholder
+	s.clearInput()
+}
+
+// handleApprovalKey handles the keys while a tool call waits for approval: Y approves the call,
+// A approves the tool for the rest of the session and N denies the call along with an optional
+// reason, written in the composer and sent with Enter
+func (s *session) handleApprovalKey(msg tea.KeyMsg) tea.Cmd {
+	if s.approval.denying {
+		if msg.String() == "enter" {
+			s.answerApproval(approvalDecision{reason: strings.TrimSpace(s.textInput.Value())})
+			return nil
+		}
+
+		var cmd tea.Cmd
+		s.textInput, cmd = s.textInput.Update(msg)
+		s.resizeComposer()
+		return cmd
+	}
+
+	switch strings.ToLower(msg.String()) {
+	case "y":
+		s.answerApproval(approvalDecision{allowed: true})
+	case "a":
+		s.answerApproval(approvalDecision{allowed: true, always: true})
+	case "n":
+		s.approval.denying = true
+		s.textInput.Placeholder = denialPlaceholder
+		s.clearInput()
+	}
+
+	return nil
+}
+
+// approvalView renders the approval prompt of the pending tool call
+func (s *session) approvalView() string {
+	prompt := utils.OtherMessage("🔐 Allow tool " + summaryLine(describeToolCall(s.approval.approval), s.contentWidth()) + "?")
+
+	if s.approval.denying {
+		return prompt + "\n" + s.textInput.View()
+	}
+
+	return prompt + "\n" + utils.OtherMessage("[y] once  [a] always for this session  [n] deny  [Esc] cancel the response")
+}
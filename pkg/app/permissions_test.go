@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thejasmeetsingh/oclai/pkg/ollama"
+)
+
+func TestGetToolPolicy(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []string
+		tool  string
+		want  toolPolicy
+	}{
+		{"no rules", nil, "write_file", policyAsk},
+		{"no matching rule", []string{"fetch=allow"}, "write_file", policyAsk},
+		{"server rule", []string{"filesystem=allow"}, "write_file", policyAllow},
+		{"tool rule", []string{"filesystem/write_*=deny"}, "write_file", policyDeny},
+		{"first match applies", []string{"filesystem/read_*=allow", "filesystem/*=deny"}, "read_file", policyAllow},
+		{"case-insensitive", []string{"FileSystem/Write_File=allow"}, "WRITE_FILE", policyAllow},
+		{"invalid rules are skipped", []string{"filesystem=maybe", "*=deny"}, "write_file", policyDeny},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getToolPolicy(tt.rules, "filesystem", tt.tool); got != tt.want {
+				t.Errorf("getToolPolicy(%q, filesystem, %s) = %s, want %s", tt.rules, tt.tool, got, tt.want)
+			}
+		})
+	}
+}
+
+// testApproval returns an approval request of the given tool
+func testApproval(tool string) toolApproval {
+	call := ollama.ToolCall{}
+	call.Function.Name = tool
+	return toolApproval{server: "filesystem", call: call}
+}
+
+func TestTerminalApproverSharesTheInput(t *testing.T) {
+	approver := newTerminalApprover(strings.NewReader("y\na\nn\nNot this file\n"), io.Discard)
+
+	want := []approvalDecision{
+		{allowed: true},
+		{allowed: true, always: true},
+		{reason: "Not this file"},
+		{}, // End of the input
+	}
+
+	for idx, expected := range want {
+		decision, err := approver.ask(context.Background(), testApproval("write_file"))
+		if err != nil {
+			t.Fatalf("approval %d: %s", idx, err)
+		}
+
+		if decision != expected {
+			t.Errorf("approval %d = %+v, want %+v", idx, decision, expected)
+		}
+	}
+}
+
+func TestTerminalApproverKeepsTheLineOfACancelledPrompt(t *testing.T) {
+	reader, writer := io.Pipe()
+	defer writer.Close()
+
+	approver := newTerminalApprover(reader, io.Discard)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := approver.ask(ctx, testApproval("write_file")); err == nil {
+		t.Fatal("expected the cancelled prompt to fail")
+	}
+
+	// The answer typed after the cancellation goes to the next prompt
+	go writer.Write([]byte("yes\n"))
+
+	decision, err := approver.ask(context.Background(), testApproval("move_file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !decision.allowed {
+		t.Errorf("decision = %+v, want the call to be allowed", decision)
+	}
+}
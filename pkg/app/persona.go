@@ -0,0 +1,71 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/thejasmeetsingh/oclai/pkg/utils"
+)
+
+const (
+	// personasDirName is the name of the directory, under the application root, holding the persona files
+	personasDirName = "personas"
+
+	// personaFileExt is the extension of the persona files
+	personaFileExt = ".md"
+)
+
+// listPersonas returns the sorted names of the available personas
+func listPersonas() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(rootPath, personasDirName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var personas []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), personaFileExt) {
+			personas = append(personas, strings.TrimSuffix(entry.Name(), personaFileExt))
+		}
+	}
+	slices.Sort(personas)
+
+	return personas, nil
+}
+
+// loadPersona returns the system prompt of the given persona
+func loadPersona(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("persona name cannot be empty")
+	}
+
+	// The persona files are looked up in the personas directory only
+	if !isValidFileName(name) {
+		return "", fmt.Errorf("invalid persona name: '%s'", name)
+	}
+
+	filePath := filepath.Join(rootPath, personasDirName, name+personaFileExt)
+	return readPromptFile(filePath)
+}
+
+// readPromptFile reads a system prompt from the given file
+func readPromptFile(filePath string) (string, error) {
+	contents, err := utils.ReadFileContent(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	prompt := strings.TrimSpace(strings.Join(contents, "\n"))
+	if prompt == "" {
+		return "", fmt.Errorf("'%s' file is empty", filePath)
+	}
+
+	return prompt, nil
+}
@@ -0,0 +1,61 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadPersona(t *testing.T) {
+	rootPath = t.TempDir()
+
+	files := map[string]string{
+		filepath.Join(personasDirName, "reviewer.md"): "Review the code",
+		"config.md":                       "Not a persona",
+		filepath.Join("sessions", "1.md"): "Not a persona either",
+	}
+	for name, content := range files {
+		path := filepath.Join(rootPath, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		persona string
+		want    string
+		wantErr string
+	}{
+		{name: "persona", persona: " reviewer ", want: "Review the code"},
+		{name: "empty name", persona: " ", wantErr: "persona name cannot be empty"},
+		{name: "parent directory", persona: "../config", wantErr: "invalid persona name: '../config'"},
+		{name: "sibling directory", persona: "../sessions/1", wantErr: "invalid persona name: '../sessions/1'"},
+		{name: "sub directory", persona: "team/reviewer", wantErr: "invalid persona name: 'team/reviewer'"},
+		{name: "dot name", persona: "..", wantErr: "invalid persona name: '..'"},
+		{name: "absolute path", persona: filepath.Join(rootPath, "config"), wantErr: "invalid persona name: '" + filepath.Join(rootPath, "config") + "'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadPersona(tt.persona)
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("loadPersona(%q) error = %v, want %q", tt.persona, err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("loadPersona(%q) = %q, want %q", tt.persona, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/thejasmeetsingh/oclai/pkg/utils"
+)
+
+var (
+	// ProfileCmd is the root command for profile management
+	ProfileCmd = &cobra.Command{
+		Use:   "profile",
+		Short: "Manage configuration profiles",
+		Long:  utils.InfoBox("Manage configuration profiles. A profile is a named set of settings (Ollama BaseURL, default model, context limit, system prompt and enabled MCP servers).\nSelect a profile for a single run with '--profile' or the OCLAI_PROFILE environment variable."),
+		Example: `
+		oclai profile add gpu --baseURL http://gpu-box:11434 --model qwen3:32b --ctx 32000
+		oclai profile use gpu
+		oclai profile ls
+		oclai profile rm gpu
+		oclai q "Hey what's up" --profile gpu
+	`,
+	}
+
+	// addProfileCmd adds a new profile
+	addProfileCmd = &cobra.Command{
+		Use:     "add [name]",
+		Short:   "Add a profile",
+		Long:    utils.InfoBox("Add a profile. The settings which are not provided take their default value."),
+		Example: "oclai profile add ci --baseURL http://localhost:11434 --model gemma3:1b --ctx 4000 --mcp filesystem",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			name := strings.TrimSpace(args[0])
+
+			// Validate the profile name
+			if name == "" {
+				fmt.Println(utils.ErrorMessage("Please provide the profile name 😒"))
+				os.Exit(1)
+			}
+
+			if _, exists := userConfig.Profiles[name]; exists {
+				fmt.Println(utils.ErrorMessage(fmt.Sprintf("'%s' profile already exists", name)))
+				os.Exit(1)
+			}
+
+			// Apply the provided settings over the defaults
+			profile := defaultProfile()
+
+			flagKeys := []struct {
+				flag string
+				key  string
+			}{
+				{flag: "baseURL", key: "baseURL"},
+				{flag: "model", key: "defaultModel"},
+				{flag: "ctx", key: "numCtx"},
+				{flag: "max-steps", key: "maxSteps"},
+				{flag: "system", key: "systemPrompt"},
+				{flag: "mcp", key: "mcpServers"},
+			}
+
+			for _, flagKey := range flagKeys {
+				if !cmd.Flags().Changed(flagKey.flag) {
+					continue
+				}
+
+				value, _ := cmd.Flags().GetString(flagKey.flag)
+				if err := configKeys[flagKey.key].set(&profile, value); err != nil {
+					fmt.Println(utils.ErrorMessage(fmt.Sprintf("Invalid '--%s' flag: %s", flagKey.flag, err.Error())))
+					os.Exit(1)
+				}
+			}
+
+			err := UpdateConfig(rootPath, func(config *Config) {
+				config.Profiles[name] = &profile
+			})
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(err.Error()))
+				os.Exit(1)
+			}
+
+			fmt.Println(utils.SuccessBox(fmt.Sprintf("'%s' profile added successfully!", name)))
+		},
+	}
+
+	// useProfileCmd changes the active profile
+	useProfileCmd = &cobra.Command{
+		Use:     "use [name]",
+		Short:   "Set the active profile",
+		Long:    utils.InfoBox("Set the active profile, which is used when no profile is selected with '--profile' or OCLAI_PROFILE."),
+		Example: "oclai profile use gpu",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			name := strings.TrimSpace(args[0])
+
+			if _, exists := userConfig.Profiles[name]; !exists {
+				fmt.Println(utils.ErrorMessage(fmt.Sprintf("'%s' profile does not exists", name)))
+				os.Exit(1)
+			}
+
+			err := UpdateConfig(rootPath, func(config *Config) {
+				config.ActiveProfile = name
+			})
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(err.Error()))
+				os.Exit(1)
+			}
+
+			fmt.Println(utils.SuccessBox(fmt.Sprintf("Switched to '%s' profile!", name)))
+		},
+	}
+
+	// listProfilesCmd lists the profiles
+	listProfilesCmd = &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the profiles",
+		Long:    utils.InfoBox("List the profiles along with their settings. The active profile is marked with a star."),
+		Example: "oclai profile ls",
+		Run: func(cmd *cobra.Command, args []string) {
+			result := "# 🗂️ Profiles\n| Name | BaseURL | Default Model | Context Limit | MCP Servers |\n| --- | --- | --- | --- | --- |\n"
+
+			for _, name := range getProfileNames() {
+				profile := userConfig.Profiles[name]
+
+				displayName := name
+				if name == userConfig.ActiveProfile {
+					displayName = "★ " + name
+				}
+
+				mcpServers := "all"
+				if len(profile.McpServers) != 0 {
+					mcpServers = strings.Join(profile.McpServers, ", ")
+				}
+
+				result += fmt.Sprintf("| %s | %s | %s | %d | %s |\n", displayName, profile.BaseURL, profile.DefaultModel, profile.NumCtx, mcpServers)
+			}
+
+			// Convert the result to markdown format
+			md, err := utils.ToMarkDown(result, utils.TerminalWidth())
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(fmt.Sprintf("Error caught while converting to markdown: %s", err)))
+				os.Exit(1)
+			}
+
+			fmt.Println(md)
+		},
+	}
+
+	// removeProfileCmd removes a profile
+	removeProfileCmd = &cobra.Command{
+		Use:     "remove [name]",
+		Aliases: []string{"rm"},
+		Short:   "Remove a profile",
+		Long:    utils.InfoBox("Remove a profile. The active profile cannot be removed."),
+		Example: "oclai profile rm gpu",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			name := strings.TrimSpace(args[0])
+
+			if _, exists := userConfig.Profiles[name]; !exists {
+				fmt.Println(utils.ErrorMessage(fmt.Sprintf("'%s' profile does not exists", name)))
+				os.Exit(1)
+			}
+
+			if name == userConfig.ActiveProfile {
+				fmt.Println(utils.ErrorMessage("The active profile cannot be removed. Please switch to another profile first"))
+				os.Exit(1)
+			}
+
+			// Fall back to the active profile if the removed one was selected for this run
+			if name == selectedProfile {
+				selectedProfile = userConfig.ActiveProfile
+			}
+
+			err := UpdateConfig(rootPath, func(config *Config) {
+				delete(config.Profiles, name)
+			})
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(err.Error()))
+				os.Exit(1)
+			}
+
+			fmt.Println(utils.SuccessBox(fmt.Sprintf("'%s' profile removed successfully!", name)))
+		},
+	}
+)
+
+// getProfileNames returns the sorted names of the profiles
+func getProfileNames() []string {
+	names := make([]string, 0, len(userConfig.Profiles))
+	for name := range userConfig.Profiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+func init() {
+	// Add sub-commands to profile root cmd
+	ProfileCmd.AddCommand(addProfileCmd, useProfileCmd, listProfilesCmd, removeProfileCmd)
+
+	// Register add profile command flags
+	addProfileCmd.Flags().String("baseURL", "", "Ollama BaseURL")
+	addProfileCmd.Flags().String("model", "", "Default model")
+	addProfileCmd.Flags().String("ctx", "", "Context limit")
+	addProfileCmd.Flags().String("max-steps", "", "Maximum tool-calling steps per request")
+	addProfileCmd.Flags().String("system", "", "System prompt of the chat sessions")
+	addProfileCmd.Flags().String("mcp", "", "Enabled MCP servers (comma separated), all of them if not provided")
+}
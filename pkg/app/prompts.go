@@ -0,0 +1,216 @@
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/thejasmeetsingh/oclai/pkg/utils"
+)
+
+const (
+	// promptHistoryFileName is the name of the file, under the application root, holding the prompt history
+	promptHistoryFileName = "history"
+
+	// maxPromptHistory is the maximum number of prompts kept in the history
+	maxPromptHistory = 1000
+)
+
+type (
+	// promptHistory holds the prompts sent in the chat sessions, the oldest first.
+	// The prompts are stored as one JSON string per line, so multiline prompts fit on a line.
+	promptHistory struct {
+		filePath string
+		entries  []string
+
+		// index is the position of the recalled prompt, len(entries) when no prompt is recalled
+		index int
+
+		// draft holds the draft being written before the history was browsed
+		draft string
+	}
+
+	// promptSearch holds the state of an incremental reverse search in the prompt history
+	promptSearch struct {
+		active bool
+		query  string
+		match  int // Index of the matching prompt, -1 if there is none
+		draft  string
+	}
+)
+
+// loadPromptHistory reads the prompt history file. A missing or unreadable history starts empty.
+func loadPromptHistory() *promptHistory {
+	h := &promptHistory{filePath: filepath.Join(rootPath, promptHistoryFileName)}
+
+	file, err := os.Open(h.filePath)
+	if err == nil {
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			var prompt string
+			if json.Unmarshal(scanner.Bytes(), &prompt) == nil && prompt != "" {
+				h.entries = append(h.entries, prompt)
+			}
+		}
+	}
+
+	h.index = len(h.entries)
+	return h
+}
+
+// add records the given prompt in the history and writes the history file.
+// An earlier identical prompt is removed, so each prompt is kept once, at its latest position.
+func (h *promptHistory) add(prompt string) error {
+	h.entries = slices.DeleteFunc(h.entries, func(entry string) bool {
+		return entry == prompt
+	})
+	h.entries = append(h.entries, prompt)
+
+	if len(h.entries) > maxPromptHistory {
+		h.entries = h.entries[len(h.entries)-maxPromptHistory:]
+	}
+
+	h.index = len(h.entries)
+	h.draft = ""
+
+	var data strings.Builder
+	for _, entry := range h.entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		data.Write(line)
+		data.WriteString("\n")
+	}
+
+	return utils.WriteFileContents(h.filePath, []byte(data.String()))
+}
+
+// previous returns the prompt sent before the recalled one, the given draft is kept to be restored later.
+// It reports false if there is no older prompt.
+func (h *promptHistory) previous(draft string) (string, bool) {
+	if h.index == 0 {
+		return "", false
+	}
+
+	if h.index == len(h.entries) {
+		h.draft = draft
+	}
+
+	h.index--
+	return h.entries[h.index], true
+}
+
+// next returns the prompt sent after the recalled one, or the draft once the end of the history is reached.
+// It reports false if no prompt is recalled.
+func (h *promptHistory) next() (string, bool) {
+	if h.index >= len(h.entries) {
+		return "", false
+	}
+
+	h.index++
+	if h.index == len(h.entries) {
+		return h.draft, true
+	}
+
+	return h.entries[h.index], true
+}
+
+// search returns the index of the most recent prompt before the given index containing the query,
+// case-insensitively, or -1 if there is none
+func (h *promptHistory) search(query string, before int) int {
+	query = strings.ToLower(query)
+
+	for i := min(before, len(h.entries)) - 1; i >= 0; i-- {
+		if strings.Contains(strings.ToLower(h.entries[i]), query) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// recallPrompt replaces the draft with the given prompt from the history
+func (s *session) recallPrompt(prompt string) {
+	s.textInput.SetValue(prompt)
+	s.resizeComposer()
+	s.updateSuggestions()
+}
+
+// startSearch starts an incremental reverse search in the prompt history
+func (s *session) startSearch() {
+	s.search = promptSearch{
+		active: true,
+		match:  -1,
+		draft:  s.textInput.Value(),
+	}
+}
+
+// handleSearchKey handles the keys while searching the prompt history: the typed text refines the search,
+// Ctrl+R looks for an older match, Enter accepts the match and Esc or Ctrl+G restores the draft
+func (s *session) handleSearchKey(msg tea.KeyMsg) {
+	switch msg.String() {
+	case "ctrl+r":
+		before := len(s.prompts.entries)
+		if s.search.match != -1 {
+			before = s.search.match
+		}
+		if match := s.prompts.search(s.search.query, before); match != -1 {
+			s.search.match = match
+		}
+		return
+
+	case "backspace":
+		if query := []rune(s.search.query); len(query) != 0 {
+			s.search.query = string(query[:len(query)-1])
+		}
+
+	case "esc", "ctrl+g", "ctrl+c":
+		s.search.active = false
+		s.recallPrompt(s.search.draft)
+		return
+
+	case "enter", "tab", "left", "right", "up", "down":
+		s.search.active = false
+		if s.search.match != -1 {
+			s.recallPrompt(s.prompts.entries[s.search.match])
+		}
+		return
+
+	default:
+		switch msg.Type {
+		case tea.KeyRunes:
+			s.search.query += string(msg.Runes)
+		case tea.KeySpace:
+			s.search.query += " "
+		default:
+			return
+		}
+	}
+
+	// Search again from the most recent prompt whenever the query changes
+	s.search.match = -1
+	if s.search.query != "" {
+		s.search.match = s.prompts.search(s.search.query, len(s.prompts.entries))
+	}
+}
+
+// searchView renders the reverse search prompt along with the matching prompt
+func (s *session) searchView() string {
+	match := ""
+	if s.search.match != -1 {
+		match = s.prompts.entries[s.search.match]
+	} else if s.search.query != "" {
+		match = utils.ErrorMessage("no match")
+	}
+
+	return utils.OtherMessage(fmt.Sprintf("🔍 (reverse-i-search)'%s': ", s.search.query)) + strings.ReplaceAll(match, "\n", " ⏎ ")
+}
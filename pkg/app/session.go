@@ -2,23 +2,30 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
-	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/thejasmeetsingh/oclai/pkg/mcp"
 	"github.com/thejasmeetsingh/oclai/pkg/ollama"
 	"github.com/thejasmeetsingh/oclai/pkg/utils"
 )
 
 // Constants for message types used in the application
 const (
-	height int = 20  // Height of the viewport in lines
-	width  int = 100 // Width of the viewport in characters
+	defaultHeight int = 40  // Height of the terminal in lines, until its actual size is known
+	defaultWidth  int = 100 // Width of the terminal in characters, until its actual size is known
+
+	minViewportHeight int = 3 // Minimum height of the chat history in lines
 
 	// messageType is used to differentiate between different types of messages
 	infoMsg    messageType = "info"
@@ -26,27 +33,93 @@ const (
 	errMsg     messageType = "error"
 	usrMsg     messageType = "user"
 	aiMsg      messageType = "ai"
+	mdMsg      messageType = "markdown"
+	toolMsg    messageType = "tool"
 )
 
 type (
 	messageType string
 
-	// sessionMessage represents a message with a type and content
+	// sessionMessage represents a message of the chat history with a type and content.
+	// The content of the user and AI messages is kept raw, it is only rendered for display.
 	sessionMessage struct {
-		_type   messageType
-		content string
+		_type     messageType
+		content   string
+		timestamp string
+		tool      *toolActivity // Tool call shown by the tool messages
 	}
 
 	// session represents the application state for the chat interface
 	session struct {
-		textInput        textinput.Model
+		textInput        textarea.Model
 		spinner          spinner.Model
 		vp               viewport.Model
 		modelRequest     ollama.ModelRequest
 		spinnerMsg       string
-		messagesMarkdown string
+		messagesMarkdown string // Rendered chat history
+		width            int    // Width of the terminal
+		height           int    // Height of the terminal
 		models           []ollama.ModelInfo
+		systemPrompt     string
+		maxSteps         int
 		waiting          bool
+
+		// suggestions holds the commands matching the draft, completed with Tab
+		suggestions []string
+
+		// prompts is the persistent history of the sent prompts, recalled with Up/Down and searched with Ctrl+R
+		prompts *promptHistory
+		search  promptSearch
+
+		// history is the display model of the chat, while the modelRequest messages are the
+		// conversation sent to the model. Both are kept apart, so no rendering leaks into the conversation.
+		history []sessionMessage
+
+		// streamContent holds the in-progress AI message while the response is streamed
+		streamContent string
+
+		// events receives the messages produced by an in-flight chat request
+		events chan tea.Msg
+
+		// cancel aborts the in-flight chat request, including any tool calls in progress
+		cancel context.CancelFunc
+
+		// approval is the tool call waiting for the user's approval, if any
+		approval *pendingApproval
+
+		// approvedTools holds the tools approved for the rest of the session, by "server/tool" name
+		approvedTools map[string]bool
+
+		// turnStart is the number of messages in the history before the in-flight turn's response
+		turnStart int
+
+		// header is the rendered startup message, headerKey identifies what it was rendered for
+		header    string
+		headerKey string
+
+		// record is the saved state of the conversation, autosaved after each response
+		record *chatSession
+
+		// contextTokens is the size of the conversation in tokens, as last reported by the model
+		contextTokens int
+	}
+
+	// chatChunkMsg carries a partial AI message received from the model stream
+	chatChunkMsg struct {
+		content string
+	}
+
+	// chatResponseMsg carries the final result of a chat request
+	chatResponseMsg struct {
+		response *ollama.ModelResponse
+		err      error
+	}
+
+	// compactResponseMsg carries the result of a conversation compaction
+	compactResponseMsg struct {
+		messages []ollama.Message
+		count    int
+		err      error
 	}
 
 	// commandInfo represents information about available commands
@@ -74,6 +147,38 @@ var subcommands = map[string]commandInfo{
 		name:        "/model",
 		description: "Switch to a different model. Usage: /model <modelName>",
 	},
+	"/steps": {
+		name:        "/steps",
+		description: "Show or set the tool-calling step limit. Usage: /steps [limit]",
+	},
+	"/system": {
+		name:        "/system",
+		description: "Show or replace the system prompt. Usage: /system [prompt]",
+	},
+	"/persona": {
+		name:        "/persona",
+		description: "List the personas or switch to one. Usage: /persona [name]",
+	},
+	"/compact": {
+		name:        "/compact",
+		description: "Summarize the oldest turns of the conversation to free up the context",
+	},
+	"/tool": {
+		name:        "/tool",
+		description: "Expand or collapse a tool call, the most recent one by default. Usage: /tool [number]",
+	},
+	"/mcp": {
+		name:        "/mcp",
+		description: "List the MCP servers, or turn the tools of a server on or off for this session. Usage: /mcp [on|off <server>]",
+	},
+	"/exit": {
+		name:        "/exit",
+		description: "End the session",
+	},
+	"/export": {
+		name:        "/export",
+		description: "Export the transcript, in the format of the file extension (md, json or html). Usage: /export <path>",
+	},
 }
 
 // userPromptText returns the placeholder text for the user input field
@@ -81,9 +186,9 @@ func userPromptText() string {
 	return utils.OtherMessage("💬 You: ")
 }
 
-// getMarkdownString converts content to markdown format
-func getMarkdownString(content string) string {
-	contentMD, err := utils.ToMarkDown(content)
+// getMarkdownString converts content to markdown format, wrapped at the given width
+func getMarkdownString(content string, wrapWidth int) string {
+	contentMD, err := utils.ToMarkDown(content, wrapWidth)
 	if err != nil {
 		fmt.Println(utils.ErrorMessage(err.Error()))
 		os.Exit(1)
@@ -92,42 +197,111 @@ func getMarkdownString(content string) string {
 	return contentMD
 }
 
-// initSession initializes a new session with default settings
-func initSession(modelRequest ollama.ModelRequest, models []ollama.ModelInfo) *session {
-	ti := textinput.New()
-	ti.Placeholder = "Type your message here... (try typing '/' for commands)"
-	ti.Prompt = userPromptText()
-	ti.Focus()
-	ti.Width = width
-	ti.ShowSuggestions = true
-
+// initSession initializes a new session with default settings.
+// If a saved session is given, its conversation is restored.
+func initSession(modelRequest ollama.ModelRequest, models []ollama.ModelInfo, record *chatSession) *session {
 	spinr := spinner.New()
 	spinr.Style = utils.LoaderStyle
 	spinr.Spinner = spinner.Points
 
-	vp := viewport.New(width, height)
+	vp := viewport.New(defaultWidth, defaultHeight)
 
-	return &session{
-		textInput:        ti,
+	s := &session{
+		textInput:        newComposer(),
+		prompts:          loadPromptHistory(),
 		spinner:          spinr,
 		vp:               vp,
+		width:            defaultWidth,
+		height:           defaultHeight,
 		models:           models,
 		modelRequest:     modelRequest,
+		systemPrompt:     OclaiConfig.SystemPrompt,
+		maxSteps:         OclaiConfig.MaxSteps,
 		messagesMarkdown: "",
 		spinnerMsg:       "",
 		waiting:          false,
+		events:           make(chan tea.Msg),
+		record:           record,
+		approvedTools:    make(map[string]bool),
+	}
+
+	if record == nil {
+		s.record = newChatSession()
+	} else {
+		s.restoreHistory()
+	}
+
+	s.contextTokens = estimateTokens(*modelRequest.Messages)
+	s.updateLayout()
+
+	return s
+}
+
+// restoreHistory renders the conversation of a resumed session in the chat history
+func (s *session) restoreHistory() {
+	// Tool calls waiting for their results
+	var pending []*toolActivity
+
+	for i, message := range *s.modelRequest.Messages {
+		switch {
+		case i == 0 && message.Role == ollama.SystemRole:
+			s.systemPrompt = message.Content
+		case message.Role == ollama.UserRole:
+			s.updateSessionMessages(sessionMessage{
+				_type:   usrMsg,
+				content: message.Content,
+			})
+		case message.Role == ollama.AssistantRole:
+			if message.Content != "" || len(message.ToolCalls) == 0 {
+				s.updateSessionMessages(sessionMessage{
+					_type:   aiMsg,
+					content: message.Content,
+				})
+			}
+			pending = s.restoreToolActivities(message, pending)
+		case message.Role == ollama.ToolRole && len(pending) != 0:
+			pending[0].output = message.Content
+			if output, isError := strings.CutPrefix(message.Content, "Error: "); isError {
+				pending[0].output = output
+				pending[0].status = toolError
+			}
+			if strings.HasPrefix(message.Content, deniedToolPrefix) {
+				pending[0].status = toolRejected
+			}
+			pending = pending[1:]
+		}
+	}
+
+	// Show the tool results restored after their blocks were added
+	s.rerenderHistory()
+}
+
+// saveSession autosaves the conversation to the session store.
+// Conversations without any user message are not saved.
+func (s *session) saveSession() {
+	s.record.update(s.modelRequest.Model, *s.modelRequest.Messages)
+	if !s.record.hasUserMessage() {
+		return
+	}
+
+	if err := saveChatSession(s.record); err != nil {
+		s.updateSessionMessages(sessionMessage{
+			_type:   errMsg,
+			content: fmt.Sprintf("Failed to save the session: %s", err.Error()),
+		})
 	}
 }
 
 // Init initializes the session and returns the initial commands
 func (s *session) Init() tea.Cmd {
-	return tea.Batch(textinput.Blink, s.spinner.Tick)
+	return tea.Batch(textarea.Blink, s.spinner.Tick)
 }
 
-// clearInput resets the text input field and suggestions
+// clearInput resets the composer and suggestions
 func (s *session) clearInput() {
 	s.textInput.Reset()
-	s.textInput.SetSuggestions([]string{})
+	s.suggestions = nil
+	s.resizeComposer()
 }
 
 // addModelMessage adds a new message to the model request
@@ -135,27 +309,67 @@ func (s *session) addModelMessage(message ollama.Message) {
 	*s.modelRequest.Messages = append(*s.modelRequest.Messages, message)
 }
 
-// updateSessionMessages updates the chat history with a new message
-func (s *session) updateSessionMessages(message sessionMessage) {
-	timestamp := time.Now().Format(time.Kitchen)
-
-	// Format the message based on its type
+// render formats the message for display based on its type, the markdown is wrapped at the given width
+func (message sessionMessage) render(wrapWidth int) string {
 	switch message._type {
 	case successMsg:
-		message.content = utils.SuccessBox(message.content)
+		return utils.SuccessBox(message.content)
 	case errMsg:
-		message.content = utils.ErrorBox(message.content)
+		return utils.ErrorBox(message.content)
 	case usrMsg:
-		message.content = utils.UserMsgBox(timestamp, getMarkdownString(fmt.Sprintf("*%s*", message.content)))
+		// Keep the layout of multiline messages, e.g. pasted code or stack traces
+		if strings.Contains(message.content, "\n") {
+			return utils.UserMsgBox(message.timestamp, getMarkdownString(utils.CodeBlock(message.content), wrapWidth))
+		}
+		return utils.UserMsgBox(message.timestamp, getMarkdownString(fmt.Sprintf("*%s*", message.content), wrapWidth))
 	case aiMsg:
-		message.content = utils.AiMsgBox(timestamp, message.content)
+		return utils.AiMsgBox(message.timestamp, getMarkdownString(message.content, wrapWidth))
+	case mdMsg:
+		return getMarkdownString(message.content, wrapWidth)
+	case toolMsg:
+		return message.tool.render(wrapWidth)
+	default:
+		return message.content
 	}
+}
+
+// updateSessionMessages updates the chat history with a new message
+func (s *session) updateSessionMessages(message sessionMessage) {
+	message.timestamp = time.Now().Format(time.Kitchen)
 
 	// Update the chat history with the new message
-	s.messagesMarkdown += message.content
+	s.history = append(s.history, message)
+	s.messagesMarkdown += message.render(s.contentWidth())
+
+	s.refreshViewport()
+}
+
+// contentWidth returns the width at which the messages are wrapped, leaving room for the message boxes
+func (s *session) contentWidth() int {
+	return max(s.width-4, 20)
+}
+
+// rerenderHistory renders the chat history again, e.g. after the terminal was resized
+func (s *session) rerenderHistory() {
+	var rendered strings.Builder
+	for _, message := range s.history {
+		rendered.WriteString(message.render(s.contentWidth()))
+	}
 
-	// Update the viewport with the new content and scroll to the bottom
-	s.vp.SetContent(s.messagesMarkdown)
+	s.messagesMarkdown = rendered.String()
+	s.refreshViewport()
+}
+
+// refreshViewport updates the viewport with the chat history and the in-progress AI message,
+// and scrolls to the bottom
+func (s *session) refreshViewport() {
+	content := s.messagesMarkdown
+
+	if s.streamContent != "" {
+		content += utils.AiMsgBox(time.Now().Format(time.Kitchen), getMarkdownString(s.streamContent, s.contentWidth()))
+	}
+
+	s.vp.SetContent(content)
 	s.vp.GotoBottom()
 }
 
@@ -167,12 +381,13 @@ func handleHelp(s *session) (*session, tea.Cmd) {
 	}
 	helpText += "\n## 💡 Tips:\n"
 	helpText += "- Type / to see available commands with autocomplete\n"
-	helpText += "- Type 'exit' or 'quit' to leave"
+	helpText += "- Press Ctrl+O to expand or collapse all the tool calls\n"
+	helpText += "- Type 'exit', 'quit' or '/exit' to leave"
 
 	// Update the chat history with the help message
 	s.updateSessionMessages(sessionMessage{
-		_type:   infoMsg,
-		content: getMarkdownString(helpText),
+		_type:   mdMsg,
+		content: helpText,
 	})
 	s.clearInput()
 
@@ -181,9 +396,15 @@ func handleHelp(s *session) (*session, tea.Cmd) {
 
 // handleClearHistory clears the chat history and resets the model request
 func handleClearHistory(s *session) (*session, tea.Cmd) {
-	s.modelRequest.Messages = &[]ollama.Message{ollama.SystemPromptMessage()}
+	s.modelRequest.Messages = &[]ollama.Message{ollama.SystemPromptMessage(s.systemPrompt)}
+	s.history = nil
 	s.messagesMarkdown = ""
 
+	// The cleared conversation stays saved, and a new session is started
+	s.record = newChatSession()
+	s.approvedTools = make(map[string]bool)
+	s.contextTokens = estimateTokens(*s.modelRequest.Messages)
+
 	// Update the chat history with a success message
 	s.updateSessionMessages(sessionMessage{
 		_type:   successMsg,
@@ -234,6 +455,13 @@ func handleModelSwitch(s *session, newModel string) (*session, tea.Cmd) {
 			content: "Model does not exists",
 		})
 	} else {
+		// Use the name as listed by Ollama, the lookup being case-insensitive
+		for _, model := range s.models {
+			if strings.EqualFold(model.Name, newModel) {
+				newModel = model.Name
+			}
+		}
+
 		s.modelRequest.Model = newModel
 		s.updateSessionMessages(sessionMessage{
 			_type:   successMsg,
@@ -245,37 +473,304 @@ func handleModelSwitch(s *session, newModel string) (*session, tea.Cmd) {
 	return s, nil
 }
 
-// updateSuggestions updates the text input suggestions based on the current input
-func (s *session) updateSuggestions() {
-	input := s.textInput.Value()
+// handleSteps shows or updates the tool-calling step limit of the session
+func handleSteps(s *session, args []string) (*session, tea.Cmd) {
+	defer s.clearInput()
 
-	// Only show suggestions if the input starts with a slash
-	if !strings.HasPrefix(input, "/") || len(input) <= 1 {
-		s.textInput.SetSuggestions([]string{})
-		return
+	// Show the current limit if no value is provided
+	if len(args) == 0 {
+		s.updateSessionMessages(sessionMessage{
+			_type:   successMsg,
+			content: fmt.Sprintf("Tool-calling step limit: %d", s.maxSteps),
+		})
+		return s, nil
+	}
+
+	steps, err := strconv.Atoi(args[0])
+	if err != nil || steps < 1 {
+		s.updateSessionMessages(sessionMessage{
+			_type:   errMsg,
+			content: "Step limit should be a positive integer",
+		})
+		return s, nil
+	}
+
+	s.maxSteps = steps
+	s.updateSessionMessages(sessionMessage{
+		_type:   successMsg,
+		content: fmt.Sprintf("Tool-calling step limit set to: %d", steps),
+	})
+
+	return s, nil
+}
+
+// setSystemPrompt swaps the system message of the conversation with the given prompt
+func (s *session) setSystemPrompt(prompt string) {
+	s.systemPrompt = prompt
+	message := ollama.SystemPromptMessage(prompt)
+
+	messages := *s.modelRequest.Messages
+	if len(messages) != 0 && messages[0].Role == ollama.SystemRole {
+		messages[0] = message
+	} else {
+		*s.modelRequest.Messages = append([]ollama.Message{message}, messages...)
+	}
+}
+
+// handleSystemPrompt shows or replaces the system prompt of the conversation
+func handleSystemPrompt(s *session, prompt string) (*session, tea.Cmd) {
+	defer s.clearInput()
+
+	// Show the current system prompt if no value is provided
+	if prompt == "" {
+		current := s.systemPrompt
+		if current == "" {
+			current = ollama.DefaultSystemPrompt
+		}
+
+		s.updateSessionMessages(sessionMessage{
+			_type:   mdMsg,
+			content: fmt.Sprintf("# 🧭 System Prompt\n%s", current),
+		})
+		return s, nil
+	}
+
+	s.setSystemPrompt(prompt)
+	s.updateSessionMessages(sessionMessage{
+		_type:   successMsg,
+		content: "System prompt updated!",
+	})
+
+	return s, nil
+}
+
+// handlePersona lists the personas, or switches the system prompt to the given persona
+func handlePersona(s *session, name string) (*session, tea.Cmd) {
+	defer s.clearInput()
+
+	// List the available personas if no name is provided
+	if name == "" {
+		personas, err := listPersonas()
+		if err != nil {
+			s.updateSessionMessages(sessionMessage{
+				_type:   errMsg,
+				content: err.Error(),
+			})
+			return s, nil
+		}
+
+		if len(personas) == 0 {
+			s.updateSessionMessages(sessionMessage{
+				_type:   errMsg,
+				content: "No personas found. Add a persona as a markdown file under ~/.oclai/personas/",
+			})
+			return s, nil
+		}
+
+		personasText := "# 🎭 Available Personas\n"
+		for _, persona := range personas {
+			personasText += fmt.Sprintf("- %s\n", persona)
+		}
+
+		s.updateSessionMessages(sessionMessage{
+			_type:   mdMsg,
+			content: personasText,
+		})
+		return s, nil
+	}
+
+	prompt, err := loadPersona(name)
+	if err != nil {
+		s.updateSessionMessages(sessionMessage{
+			_type:   errMsg,
+			content: err.Error(),
+		})
+		return s, nil
+	}
+
+	s.setSystemPrompt(prompt)
+	s.updateSessionMessages(sessionMessage{
+		_type:   successMsg,
+		content: "Switched to persona: " + name,
+	})
+
+	return s, nil
+}
+
+// handleExport writes the transcript of the conversation to the given path
+func handleExport(s *session, filePath string) (*session, tea.Cmd) {
+	defer s.clearInput()
+
+	s.record.update(s.modelRequest.Model, *s.modelRequest.Messages)
+
+	data, err := exportTranscript(s.record, getExportFormat(filePath))
+	if err == nil {
+		err = utils.WriteFileContents(filePath, data)
+	}
+
+	if err != nil {
+		s.updateSessionMessages(sessionMessage{
+			_type:   errMsg,
+			content: fmt.Sprintf("Failed to export the transcript: %s", err.Error()),
+		})
+		return s, nil
+	}
+
+	s.updateSessionMessages(sessionMessage{
+		_type:   successMsg,
+		content: "Transcript exported to: " + filePath,
+	})
+
+	return s, nil
+}
+
+// handleMcp lists the MCP servers, or turns the tools of a server on or off for the session
+func handleMcp(s *session, args []string) (*session, tea.Cmd) {
+	defer s.clearInput()
+
+	// List the servers along with their state if no server is provided
+	if len(args) == 0 {
+		servers := mcp.GetServerStates()
+		if len(servers) == 0 {
+			s.updateSessionMessages(sessionMessage{
+				_type:   errMsg,
+				content: "No MCP servers are available",
+			})
+			return s, nil
+		}
+
+		serversText := "# 🔌 MCP Servers\n"
+		for _, server := range servers {
+			state := "off"
+			if server.Active {
+				state = "on"
+			}
+			serversText += fmt.Sprintf("- %s: %s (%d tools)\n", server.Name, state, server.Tools)
+		}
+
+		s.updateSessionMessages(sessionMessage{
+			_type:   mdMsg,
+			content: serversText,
+		})
+		return s, nil
+	}
+
+	on := strings.EqualFold(args[0], "on")
+	if err := mcp.SetServerOverride(args[1], on); err != nil {
+		s.updateSessionMessages(sessionMessage{
+			_type:   errMsg,
+			content: err.Error(),
+		})
+		return s, nil
+	}
+
+	// Offer the tools of the servers which are on from the next request
+	s.modelRequest.Tools = mcp.GetAllTools()
+
+	state := "off"
+	if on {
+		state = "on"
 	}
 
-	// Find matching commands
-	var matches []string
-	for cmd := range subcommands {
-		if strings.HasPrefix(cmd, input) {
-			matches = append(matches, cmd)
+	s.updateSessionMessages(sessionMessage{
+		_type:   successMsg,
+		content: fmt.Sprintf("'%s' server turned %s for this session", args[1], state),
+	})
+
+	return s, nil
+}
+
+// compactConversation summarizes the oldest turns of the conversation in the background
+func (s *session) compactConversation() tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.waiting = true
+	s.spinnerMsg = "Compacting the conversation"
+
+	model := s.modelRequest.Model
+	messages := slices.Clone(*s.modelRequest.Messages)
+
+	return func() tea.Msg {
+		defer cancel()
+
+		compacted, count, err := compactMessages(ctx, model, messages)
+		if err != nil && ctx.Err() != nil {
+			err = ctx.Err()
 		}
+
+		return compactResponseMsg{messages: compacted, count: count, err: err}
 	}
+}
 
-	// Set the suggestions
-	s.textInput.SetSuggestions(matches)
+// handleCompact compacts the conversation on demand
+func handleCompact(s *session) (*session, tea.Cmd) {
+	s.clearInput()
+	return s, s.compactConversation()
+}
+
+// handleCompactResponse replaces the conversation with its compacted version
+func (s *session) handleCompactResponse(msg compactResponseMsg) {
+	s.waiting = false
+	s.spinnerMsg = ""
+	s.cancel = nil
+
+	if errors.Is(msg.err, context.Canceled) {
+		s.updateSessionMessages(sessionMessage{
+			_type:   infoMsg,
+			content: "\n" + utils.InfoMessage("Compaction cancelled ⏹") + "\n",
+		})
+		return
+	}
+
+	if msg.err != nil {
+		s.updateSessionMessages(sessionMessage{
+			_type:   errMsg,
+			content: msg.err.Error(),
+		})
+		return
+	}
+
+	*s.modelRequest.Messages = msg.messages
+	s.contextTokens = estimateTokens(msg.messages)
+	s.saveSession()
+
+	s.updateSessionMessages(sessionMessage{
+		_type:   successMsg,
+		content: fmt.Sprintf("Conversation compacted: %d messages summarized 🗜️", msg.count),
+	})
+}
+
+// contextStatus returns the context usage indicator of the conversation
+func (s *session) contextStatus() string {
+	if OclaiConfig.NumCtx <= 0 {
+		return utils.OtherMessage(fmt.Sprintf("📊 Context: %d tokens", s.contextTokens))
+	}
+
+	usage := 100 * s.contextTokens / OclaiConfig.NumCtx
+	return utils.OtherMessage(fmt.Sprintf("📊 Context: %d / %d tokens (%d%%)", s.contextTokens, OclaiConfig.NumCtx, usage))
+}
+
+// isExitInput checks whether the input asks to end the session, regardless of its case
+func isExitInput(input string) bool {
+	switch strings.ToLower(input) {
+	case "exit", "quit", "/exit", "/quit":
+		return true
+	}
+
+	return false
 }
 
 // handleCommand processes a command input
 func (s *session) handleCommand(command string) (*session, tea.Cmd) {
 	cmd := strings.Fields(command)
 
-	// Check if the command exists in the subcommands map
-	if cmdInfo, exists := subcommands[cmd[0]]; exists {
+	// Check if the command exists in the subcommands map, the arguments are kept as typed
+	if cmdInfo, exists := subcommands[strings.ToLower(cmd[0])]; exists {
 		switch cmdInfo.name {
 		case "/help":
 			return handleHelp(s)
+		case "/exit":
+			return s, tea.Quit
 		case "/clear":
 			return handleClearHistory(s)
 		case "/models":
@@ -285,42 +780,161 @@ func (s *session) handleCommand(command string) (*session, tea.Cmd) {
 				break
 			}
 			return handleModelSwitch(s, cmd[1])
+		case "/steps":
+			if len(cmd) > 2 {
+				break
+			}
+			return handleSteps(s, cmd[1:])
+		case "/system":
+			return handleSystemPrompt(s, strings.TrimSpace(strings.TrimPrefix(command, cmd[0])))
+		case "/persona":
+			if len(cmd) > 2 {
+				break
+			}
+			return handlePersona(s, strings.Join(cmd[1:], ""))
+		case "/compact":
+			return handleCompact(s)
+		case "/mcp":
+			if len(cmd) != 1 && (len(cmd) != 3 || !slices.Contains([]string{"on", "off"}, strings.ToLower(cmd[1]))) {
+				break
+			}
+			return handleMcp(s, cmd[1:])
+		case "/tool":
+			if len(cmd) > 2 {
+				break
+			}
+			return handleToolToggle(s, cmd[1:])
+		case "/export":
+			if len(cmd) != 2 {
+				break
+			}
+			return handleExport(s, cmd[1])
 		}
 	}
 
 	// Provide feedback for unknown commands
 	s.updateSessionMessages(sessionMessage{
 		_type:   errMsg,
-		content: fmt.Sprintf("Unknown command: %s. Type '/help' to view the available commands.", cmd),
+		content: fmt.Sprintf("Unknown command: %s. Type '/help' to view the available commands.", cmd[0]),
 	})
 	s.clearInput()
 
 	return s, nil
 }
 
-// sendChatRequest sends a chat request to the AI model
-func (s *session) sendChatRequest() {
-	modelResponse, err := chatWithTools(context.Background(), s.modelRequest)
-	if err != nil {
-		// Handle errors by displaying an error message
+// waitForEvent returns a command which waits for the next message of an in-flight chat request
+func (s *session) waitForEvent() tea.Cmd {
+	return func() tea.Msg {
+		return <-s.events
+	}
+}
+
+// sendChatRequest sends a chat request to the AI model in the background,
+// streaming the partial response back to the session
+func (s *session) sendChatRequest() tea.Cmd {
+	request := s.modelRequest
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.turnStart = len(*s.modelRequest.Messages)
+
+	opts := agentOptions{
+		maxSteps: s.maxSteps,
+		onChunk: func(message ollama.Message) {
+			s.events <- chatChunkMsg{content: message.Content}
+		},
+		onToolEvent: func(event toolEvent) {
+			s.events <- toolEventMsg{event: event}
+		},
+		approve:       s.requestApproval,
+		approvedTools: s.approvedTools,
+	}
+
+	go func() {
+		defer cancel()
+
+		modelResponse, err := chatWithTools(ctx, request, opts)
+
+		// Report the cancellation regardless of where the request was interrupted
+		if err != nil && ctx.Err() != nil {
+			err = ctx.Err()
+		}
+
+		s.events <- chatResponseMsg{response: modelResponse, err: err}
+	}()
+
+	return s.waitForEvent()
+}
+
+// cancelChatRequest cancels the in-flight chat request
+func (s *session) cancelChatRequest() {
+	if s.cancel != nil {
+		s.cancel()
+		s.spinnerMsg = "Cancelling"
+	}
+}
+
+// handleCancelledResponse restores the conversation to a consistent state after a cancelled turn.
+// Any partial tool exchange is discarded and a marker message is recorded in its place.
+func (s *session) handleCancelledResponse(partial string) {
+	*s.modelRequest.Messages = (*s.modelRequest.Messages)[:s.turnStart]
+
+	content := strings.TrimSpace(partial + "\n\n*[Response cancelled by user]*")
+	s.addModelMessage(ollama.Message{
+		Role:    ollama.AssistantRole,
+		Content: content,
+	})
+
+	if partial != "" {
 		s.updateSessionMessages(sessionMessage{
-			_type:   errMsg,
-			content: err.Error(),
+			_type:   aiMsg,
+			content: partial,
 		})
+	}
+	s.updateSessionMessages(sessionMessage{
+		_type:   infoMsg,
+		content: "\n" + utils.InfoMessage("Response cancelled ⏹") + "\n",
+	})
+}
+
+// handleChatResponse processes the final result of a chat request
+func (s *session) handleChatResponse(msg chatResponseMsg) {
+	partial := s.streamContent
+
+	s.waiting = false
+	s.spinnerMsg = ""
+	s.streamContent = ""
+	s.cancel = nil
+
+	// Drop the approval left pending by a cancelled request
+	if s.approval != nil {
+		s.approval = nil
+		s.textInput.Placeholder = composerPlaceholder
+		s.clearInput()
+	}
+
+	// Autosave the conversation once the turn is over
+	defer s.saveSession()
+
+	if errors.Is(msg.err, context.Canceled) {
+		s.handleCancelledResponse(partial)
 		return
 	}
 
-	// Convert the response to markdown and update the chat history
-	content, err := utils.ToMarkDown(modelResponse.Message.Content)
-	if err != nil {
+	if msg.err != nil {
+		// Handle errors by displaying an error message
 		s.updateSessionMessages(sessionMessage{
 			_type:   errMsg,
-			content: err.Error(),
+			content: msg.err.Error(),
 		})
 		return
 	}
 
-	// Add the AI response to the model request and update the chat history
+	// Track the size of the conversation, including the response
+	s.contextTokens = msg.response.PromptEvalCount + msg.response.EvalCount
+
+	// Keep the raw response in the conversation, only the chat history is rendered
+	content := msg.response.Message.Content
 	s.addModelMessage(ollama.Message{
 		Role:    ollama.AssistantRole,
 		Content: content,
@@ -329,30 +943,105 @@ func (s *session) sendChatRequest() {
 		_type:   aiMsg,
 		content: content,
 	})
-
-	s.waiting = false
-	s.spinnerMsg = ""
 }
 
-// Update handles application state updates based on the received message
+// Update handles application state updates based on the received message,
+// and lays out the interface according to the new state
 func (s *session) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := s.update(msg)
+	s.updateLayout()
+
+	return model, cmd
+}
+
+// update handles application state updates based on the received message
+func (s *session) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var (
 		cmd  tea.Cmd
 		cmds []tea.Cmd
 	)
 
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		// Follow the terminal size, the messages are wrapped again at the new width
+		s.height = msg.Height
+		if msg.Width != s.width {
+			s.width = msg.Width
+			s.vp.Width = msg.Width
+			s.textInput.SetWidth(msg.Width)
+			s.rerenderHistory()
+		}
+		return s, nil
+
 	case tea.KeyMsg:
+		if s.search.active {
+			s.handleSearchKey(msg)
+			return s, nil
+		}
+
+		// Answer the pending tool approval, the request can still be cancelled
+		if s.approval != nil && msg.String() != "ctrl+c" && msg.String() != "esc" {
+			return s, s.handleApprovalKey(msg)
+		}
+
 		switch msg.String() {
 		case "ctrl+c":
+			if s.cancel != nil {
+				s.cancel()
+			}
 			return s, tea.Quit
 
-		case "down":
-			s.vp.ScrollDown(1)
+		case "esc":
+			if s.waiting {
+				s.cancelChatRequest()
+			}
+			return s, nil
+
+		case "pgdown":
+			s.vp.PageDown()
+			return s, nil
+
+		case "pgup":
+			s.vp.PageUp()
 			return s, nil
 
 		case "up":
-			s.vp.ScrollUp(1)
+			// Recall the previous prompt from the first line of the draft, move the cursor otherwise
+			if s.textInput.Line() == 0 && !s.waiting {
+				if prompt, ok := s.prompts.previous(s.textInput.Value()); ok {
+					s.recallPrompt(prompt)
+				}
+				return s, nil
+			}
+
+		case "down":
+			// Recall the next prompt from the last line of the draft, move the cursor otherwise
+			if s.textInput.Line() == s.textInput.LineCount()-1 && !s.waiting {
+				if prompt, ok := s.prompts.next(); ok {
+					s.recallPrompt(prompt)
+				}
+				return s, nil
+			}
+
+		case "ctrl+r":
+			if !s.waiting {
+				s.startSearch()
+			}
+			return s, nil
+
+		case "tab":
+			if s.completeSuggestion() {
+				return s, nil
+			}
+
+		case "ctrl+o":
+			s.toggleToolActivities()
+			return s, nil
+
+		case "ctrl+e":
+			if !s.waiting {
+				return s, s.openEditor()
+			}
 			return s, nil
 
 		case "enter":
@@ -360,15 +1049,24 @@ func (s *session) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return s, s.spinner.Tick
 			}
 
-			input := strings.ToLower(strings.TrimSpace(s.textInput.Value()))
+			// The input is sent as typed, it is only normalized to detect the commands
+			input := strings.TrimSpace(s.textInput.Value())
 			if input == "" {
 				return s, nil
 			}
 
-			if input == "exit" || input == "quit" {
+			if isExitInput(input) {
 				return s, tea.Quit
 			}
 
+			// Record the prompt in the history, including the commands
+			if err := s.prompts.add(input); err != nil {
+				s.updateSessionMessages(sessionMessage{
+					_type:   errMsg,
+					content: fmt.Sprintf("Failed to save the prompt history: %s", err.Error()),
+				})
+			}
+
 			if strings.HasPrefix(input, "/") {
 				return s.handleCommand(input)
 			}
@@ -388,10 +1086,47 @@ func (s *session) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			s.spinnerMsg = "Thinking"
 
 			s.clearInput()
-			go s.sendChatRequest()
 
-			return s, nil
+			return s, s.sendChatRequest()
+		}
+
+	case chatChunkMsg:
+		// Render the in-progress AI message and wait for the next chunk
+		s.streamContent += msg.content
+		s.refreshViewport()
+		return s, s.waitForEvent()
+
+	case approvalRequestMsg:
+		// Ask the user to approve the tool call, the request waits for the answer
+		s.approval = &pendingApproval{approval: msg.approval, reply: msg.reply}
+		s.spinnerMsg = "Waiting for approval"
+		return s, s.waitForEvent()
+
+	case toolEventMsg:
+		// Show the progress of the tool call, the request goes on even if the tool failed
+		s.handleToolEvent(msg.event)
+		return s, s.waitForEvent()
+
+	case chatResponseMsg:
+		s.handleChatResponse(msg)
+
+		// Compact the conversation before it overflows the context, which would truncate it
+		if start, end := getCompactionRange(*s.modelRequest.Messages); msg.err == nil && isContextNearLimit(s.contextTokens) && start != end {
+			s.updateSessionMessages(sessionMessage{
+				_type:   infoMsg,
+				content: "\n" + utils.InfoMessage("The context is nearly full, compacting the conversation") + "\n",
+			})
+			return s, s.compactConversation()
 		}
+		return s, nil
+
+	case compactResponseMsg:
+		s.handleCompactResponse(msg)
+		return s, nil
+
+	case editorFinishedMsg:
+		s.handleEditorFinished(msg)
+		return s, nil
 
 	case spinner.TickMsg:
 		var cmd tea.Cmd
@@ -399,55 +1134,107 @@ func (s *session) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return s, cmd
 	}
 
-	// Update text input and viewport
-	prevValue := s.textInput.Value()
-	s.textInput, cmd = s.textInput.Update(msg)
-	cmds = append(cmds, cmd)
+	// Update the composer, the keys are for the draft while it is editable
+	if _, isKey := msg.(tea.KeyMsg); !isKey || !s.waiting {
+		prevValue := s.textInput.Value()
+		s.textInput, cmd = s.textInput.Update(msg)
+		cmds = append(cmds, cmd)
 
-	if s.textInput.Value() != prevValue {
-		s.updateSuggestions()
+		if s.textInput.Value() != prevValue {
+			s.resizeComposer()
+			s.updateSuggestions()
+		}
 	}
 
-	s.vp, cmd = s.vp.Update(msg)
-	cmds = append(cmds, cmd)
+	// Update the viewport with the other events, e.g. the mouse wheel
+	if _, isKey := msg.(tea.KeyMsg); !isKey {
+		s.vp, cmd = s.vp.Update(msg)
+		cmds = append(cmds, cmd)
+	}
 
 	return s, tea.Batch(cmds...)
 }
 
+// startupText returns the startup message with the application information.
+// The compact message only keeps the essentials, for small terminals.
+func (s *session) startupText(compact bool) string {
+	startupTxt := fmt.Sprintf("# 🚀 Starting interactive session with *%s*\n", s.modelRequest.Model)
+	if compact {
+		return startupTxt + "Type `/help` for available commands, `exit` or `Ctrl+C` to end the session."
+	}
+
+	startupTxt += "- Type `exit`, `quit`, or press `Ctrl+C` to end the session.\n"
+	startupTxt += "- Press `Esc` to cancel a response in progress.\n"
+	startupTxt += "- Press `Alt+Enter` (or `Shift+Enter`) for a new line, and `Ctrl+E` to write the message in your editor.\n"
+	startupTxt += "- Press `Up`/`Down` to recall the previous prompts, `Ctrl+R` to search them, and `PgUp`/`PgDn` to scroll.\n"
+	startupTxt += "- Type `/help` for available commands.\n"
+	startupTxt += fmt.Sprintf("- The session is saved automatically, resume it with `oclai chat --resume %s`.", s.record.ID)
+
+	return startupTxt
+}
+
+// footerView renders the section below the chat history: the spinner, the search or the composer,
+// along with the context usage
+func (s *session) footerView() string {
+	var bottom string
+
+	// Display the approval prompt, the spinner or text input based on waiting state
+	if s.approval != nil {
+		bottom = s.approvalView()
+	} else if s.waiting {
+		bottom = s.spinnerMsg + " " + s.spinner.View()
+	} else if s.search.active {
+		bottom = s.searchView()
+	} else {
+		bottom = s.textInput.View() + s.suggestionsView()
+	}
+
+	return bottom + "\n\n" + s.contextStatus()
+}
+
+// updateLayout sizes the chat history to the space left by the header and the footer
+func (s *session) updateLayout() {
+	// Render the header again only if its content or width changed
+	headerKey := fmt.Sprintf("%dx%d/%s/%s", s.width, s.height, s.modelRequest.Model, s.record.ID)
+	if headerKey != s.headerKey {
+		s.header = getMarkdownString(s.startupText(false), s.contentWidth())
+		s.headerKey = headerKey
+
+		// Keep most of a small terminal for the chat history
+		if lipgloss.Height(s.header) > s.height/3 {
+			s.header = getMarkdownString(s.startupText(true), s.contentWidth())
+		}
+	}
+
+	// Leave room for the blank lines separating the sections
+	vpHeight := max(s.height-lipgloss.Height(s.header)-lipgloss.Height(s.footerView())-2, minViewportHeight)
+	if vpHeight != s.vp.Height {
+		atBottom := s.vp.AtBottom()
+		s.vp.Height = vpHeight
+		if atBottom {
+			s.vp.GotoBottom()
+		}
+	}
+}
+
 // View renders the application interface
 func (s *session) View() string {
 	var (
 		output strings.Builder
 
 		// Sections
-		top    string
 		middle string
-		bottom string
 	)
 
-	// Startup message with application information
-	startupTxt := fmt.Sprintf("# 🚀 Starting interactive session with *%s*\n", s.modelRequest.Model)
-	startupTxt += "- Type `exit`, `quit`, or press `Ctrl+C` to end the session.\n"
-	startupTxt += "- Type `/help` for available commands."
-
-	top = getMarkdownString(startupTxt)
-
 	// Display chat history or a prompt if it's empty
 	if s.messagesMarkdown == "" {
-		middle = getMarkdownString("*Start the conversation by typing a message below!*")
+		middle = getMarkdownString("*Start the conversation by typing a message below!*", s.contentWidth())
 	} else {
 		middle = s.vp.View()
 	}
 
-	// Display spinner or text input based on waiting state
-	if s.waiting {
-		bottom = s.spinnerMsg + " " + s.spinner.View()
-	} else {
-		bottom = s.textInput.View()
-	}
-
 	// Combine all sections and return the final output
-	output.WriteString(top + "\n\n" + middle + "\n\n" + bottom)
+	output.WriteString(s.header + "\n\n" + middle + "\n\n" + s.footerView())
 
 	return output.String()
 }
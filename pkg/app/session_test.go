@@ -0,0 +1,345 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/thejasmeetsingh/oclai/pkg/ollama"
+)
+
+// newTestSession creates a chat session talking to the Ollama API at the given URL
+func newTestSession(t *testing.T, baseURL string) *session {
+	t.Helper()
+
+	rootPath = t.TempDir()
+	OclaiConfig = Profile{BaseURL: baseURL, DefaultModel: "test", NumCtx: 2048, MaxSteps: 3}
+
+	request := ollama.ModelRequest{
+		Model:    "test",
+		Messages: &[]ollama.Message{ollama.SystemPromptMessage("")},
+	}
+
+	return initSession(request, []ollama.ModelInfo{{Name: "test"}, {Name: "Qwen3:8B"}}, nil)
+}
+
+// sendPrompt types the given prompt in the composer and sends it, waiting for the end of the turn
+func sendPrompt(t *testing.T, s *session, prompt string) {
+	t.Helper()
+
+	s.textInput.SetValue(prompt)
+	s.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	for s.waiting {
+		select {
+		case msg := <-s.events:
+			s.Update(msg)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the chat response")
+		}
+	}
+}
+
+// chatServer is a fake Ollama API, streaming the same answer to every chat request
+type chatServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []ollama.ModelRequest
+}
+
+// newChatServer starts a fake Ollama API answering the chat requests with the given answer
+func newChatServer(t *testing.T, answer string) *chatServer {
+	t.Helper()
+
+	server := &chatServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			http.NotFound(w, r)
+			return
+		}
+
+		var request ollama.ModelRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		server.mu.Lock()
+		server.requests = append(server.requests, request)
+		server.mu.Unlock()
+
+		// Stream the answer in a few chunks, as Ollama does
+		encoder := json.NewEncoder(w)
+		for rest := answer; rest != ""; {
+			size := min(len(rest), 16)
+			encoder.Encode(ollama.ModelResponse{Message: ollama.Message{Role: ollama.AssistantRole, Content: rest[:size]}})
+			rest = rest[size:]
+		}
+		encoder.Encode(ollama.ModelResponse{Done: true, Message: ollama.Message{Role: ollama.AssistantRole}})
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// sentMessages returns the messages of the chat requests received by the server, in order
+func (c *chatServer) sentMessages() [][]ollama.Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var messages [][]ollama.Message
+	for _, request := range c.requests {
+		messages = append(messages, *request.Messages)
+	}
+
+	return messages
+}
+
+func TestChatHistorySentToModelHasNoANSI(t *testing.T) {
+	answer := "# Title\n\nSome **bold** text and `code`:\n\n```go\nfunc main() {\n\tfmt.Println(1)\n}\n```\n\n    indented block"
+
+	server := newChatServer(t, answer)
+	s := newTestSession(t, server.URL)
+
+	sendPrompt(t, s, "first question")
+	sendPrompt(t, s, "second question")
+
+	// The answer is rendered in the chat history
+	if !strings.Contains(s.messagesMarkdown, "\x1b[") {
+		t.Fatal("expected the chat history to be rendered with ANSI sequences")
+	}
+
+	requests := server.sentMessages()
+	if len(requests) != 2 {
+		t.Fatalf("got %d chat requests, want 2", len(requests))
+	}
+
+	// The follow-up request carries the raw answer of the first turn
+	var assistant []string
+	for _, message := range requests[1] {
+		if strings.Contains(message.Content, "\x1b") {
+			t.Errorf("%s message sent to the model contains ANSI sequences: %q", message.Role, message.Content)
+		}
+		if message.Role == ollama.AssistantRole {
+			assistant = append(assistant, message.Content)
+		}
+	}
+
+	if len(assistant) != 1 || assistant[0] != answer {
+		t.Errorf("assistant messages sent to the model = %q, want [%q]", assistant, answer)
+	}
+}
+
+func TestHandleCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		command     string
+		wantModel   string
+		wantPrompt  string
+		wantQuit    bool
+		wantMessage string // Last message of the chat history
+		wantMsgType messageType
+	}{
+		{
+			name:        "model name keeps its case",
+			command:     "/MODEL Qwen3:8B",
+			wantModel:   "Qwen3:8B",
+			wantMessage: "Switched to model: Qwen3:8B",
+			wantMsgType: successMsg,
+		},
+		{
+			name:        "model name is matched case-insensitively",
+			command:     "/model qwen3:8b",
+			wantModel:   "Qwen3:8B",
+			wantMessage: "Switched to model: Qwen3:8B",
+			wantMsgType: successMsg,
+		},
+		{
+			name:        "unknown model",
+			command:     "/model Missing:1B",
+			wantModel:   "test",
+			wantMessage: "Model does not exists",
+			wantMsgType: errMsg,
+		},
+		{
+			name:        "system prompt keeps its case",
+			command:     "/System Answer in French, cite RFC 9110",
+			wantModel:   "test",
+			wantPrompt:  "Answer in French, cite RFC 9110",
+			wantMessage: "System prompt updated!",
+			wantMsgType: successMsg,
+		},
+		{
+			name:      "exit command",
+			command:   "/EXIT",
+			wantModel: "test",
+			wantQuit:  true,
+		},
+		{
+			name:        "unknown command",
+			command:     "/Unknown Arg",
+			wantModel:   "test",
+			wantMessage: "Unknown command: /Unknown. Type '/help' to view the available commands.",
+			wantMsgType: errMsg,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSession(t, "http://127.0.0.1:0")
+
+			_, cmd := s.handleCommand(tt.command)
+
+			if s.modelRequest.Model != tt.wantModel {
+				t.Errorf("model = %q, want %q", s.modelRequest.Model, tt.wantModel)
+			}
+
+			if tt.wantPrompt != "" && s.systemPrompt != tt.wantPrompt {
+				t.Errorf("system prompt = %q, want %q", s.systemPrompt, tt.wantPrompt)
+			}
+
+			if isQuit(cmd) != tt.wantQuit {
+				t.Errorf("quit = %t, want %t", isQuit(cmd), tt.wantQuit)
+			}
+
+			if tt.wantMessage != "" {
+				last := s.history[len(s.history)-1]
+				if last.content != tt.wantMessage || last._type != tt.wantMsgType {
+					t.Errorf("last message = %q (%s), want %q (%s)", last.content, last._type, tt.wantMessage, tt.wantMsgType)
+				}
+			}
+		})
+	}
+}
+
+func TestIsExitInput(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"exit", true},
+		{"EXIT", true},
+		{"Quit", true},
+		{"/exit", true},
+		{"/QUIT", true},
+		{"exit now", false},
+		{"/exited", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := isExitInput(tt.input); got != tt.want {
+			t.Errorf("isExitInput(%q) = %t, want %t", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestInputPipeline(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantSent  string // User message sent to the model, if any
+		wantModel string
+		wantQuit  bool
+	}{
+		{
+			name:      "message keeps its case",
+			input:     "Explain func ParseURL in Main.go",
+			wantSent:  "Explain func ParseURL in Main.go",
+			wantModel: "test",
+		},
+		{
+			name:      "surrounding whitespace is trimmed",
+			input:     "  \n\tWhat does HTTP_PROXY do?  \n",
+			wantSent:  "What does HTTP_PROXY do?",
+			wantModel: "test",
+		},
+		{
+			name:      "inner new lines are kept",
+			input:     "Line One\n  Line Two",
+			wantSent:  "Line One\n  Line Two",
+			wantModel: "test",
+		},
+		{
+			name:      "command with surrounding whitespace",
+			input:     "  /MODEL Qwen3:8B  ",
+			wantModel: "Qwen3:8B",
+		},
+		{
+			name:      "exit in upper case",
+			input:     "EXIT",
+			wantModel: "test",
+			wantQuit:  true,
+		},
+		{
+			name:      "exit command with whitespace",
+			input:     " /exit ",
+			wantModel: "test",
+			wantQuit:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newChatServer(t, "Answer")
+			s := newTestSession(t, server.URL)
+
+			s.textInput.SetValue(tt.input)
+			_, cmd := s.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+			if tt.wantQuit {
+				if !isQuit(cmd) {
+					t.Error("expected the input to end the session")
+				}
+				return
+			}
+
+			// Wait for the end of the turn, if a message was sent
+			for s.waiting {
+				select {
+				case msg := <-s.events:
+					s.Update(msg)
+				case <-time.After(5 * time.Second):
+					t.Fatal("timed out waiting for the chat response")
+				}
+			}
+
+			if s.modelRequest.Model != tt.wantModel {
+				t.Errorf("model = %q, want %q", s.modelRequest.Model, tt.wantModel)
+			}
+
+			requests := server.sentMessages()
+			if tt.wantSent == "" {
+				if len(requests) != 0 {
+					t.Errorf("expected no chat request, got %d", len(requests))
+				}
+				return
+			}
+
+			if len(requests) != 1 {
+				t.Fatalf("got %d chat requests, want 1", len(requests))
+			}
+
+			messages := requests[0]
+			if last := messages[len(messages)-1]; last.Role != ollama.UserRole || last.Content != tt.wantSent {
+				t.Errorf("sent %s message %q, want user message %q", last.Role, last.Content, tt.wantSent)
+			}
+		})
+	}
+}
+
+// isQuit checks whether the given command ends the program
+func isQuit(cmd tea.Cmd) bool {
+	if cmd == nil {
+		return false
+	}
+
+	_, ok := cmd().(tea.QuitMsg)
+	return ok
+}
@@ -0,0 +1,188 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/thejasmeetsingh/oclai/pkg/utils"
+)
+
+var (
+	// SessionsCmd is the root command for the saved chat sessions management
+	SessionsCmd = &cobra.Command{
+		Use:   "sessions",
+		Short: "Manage saved chat sessions",
+		Long:  utils.InfoBox("Manage the chat sessions saved under ~/.oclai/sessions. Every chat session is saved automatically, and can be resumed with 'oclai chat --resume <id>' or 'oclai chat --continue'."),
+		Example: `
+		oclai sessions ls
+		oclai sessions show 20250101-093000
+		oclai sessions rename 20250101-093000 "Debugging the MCP timeout"
+		oclai sessions export 20250101-093000 --format html transcript.html
+		oclai sessions rm 20250101-093000
+	`,
+	}
+
+	// listSessionsCmd lists the saved sessions
+	listSessionsCmd = &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the saved sessions",
+		Long:    utils.InfoBox("List the saved chat sessions, the most recently updated first."),
+		Example: "oclai sessions ls",
+		Run: func(cmd *cobra.Command, args []string) {
+			sessions, err := listChatSessions()
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(fmt.Sprintf("Error listing sessions: %s", err.Error())))
+				os.Exit(1)
+			}
+
+			if len(sessions) == 0 {
+				fmt.Println(utils.InfoMessage("No saved sessions found"))
+				return
+			}
+
+			result := "# 💾 Sessions\n| ID | Title | Model | Messages | Updated |\n| --- | --- | --- | --- | --- |\n"
+
+			for _, c := range sessions {
+				title := strings.ReplaceAll(c.Title, "|", "\\|")
+				result += fmt.Sprintf("| %s | %s | %s | %d | %s |\n", c.ID, title, c.Model, len(c.Messages), c.UpdatedAt.Format(time.DateTime))
+			}
+
+			// Convert the result to markdown format
+			md, err := utils.ToMarkDown(result, utils.TerminalWidth())
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(fmt.Sprintf("Error caught while converting to markdown: %s", err)))
+				os.Exit(1)
+			}
+
+			fmt.Println(md)
+		},
+	}
+
+	// showSessionCmd shows the conversation of a saved session
+	showSessionCmd = &cobra.Command{
+		Use:     "show [id]",
+		Short:   "Show a saved session",
+		Long:    utils.InfoBox("Show the conversation of a saved chat session, including the tool calls and their results."),
+		Example: "oclai sessions show 20250101-093000",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			c, err := loadChatSession(args[0])
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(err.Error()))
+				os.Exit(1)
+			}
+
+			// Convert the transcript to markdown format
+			md, err := utils.ToMarkDown(transcriptMarkdown(c), utils.TerminalWidth())
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(fmt.Sprintf("Error caught while converting to markdown: %s", err)))
+				os.Exit(1)
+			}
+
+			fmt.Println(md)
+		},
+	}
+
+	// exportSessionCmd exports the transcript of a saved session
+	exportSessionCmd = &cobra.Command{
+		Use:   "export [id] [path]",
+		Short: "Export a saved session",
+		Long:  utils.InfoBox("Export the transcript of a saved chat session, including the tool calls and their results.\nThe transcript is written to the given path, or printed if no path is provided."),
+		Example: `
+		oclai sessions export 20250101-093000 transcript.md
+		oclai sessions export 20250101-093000 --format json > transcript.json
+	`,
+		Args: cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			c, err := loadChatSession(args[0])
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(err.Error()))
+				os.Exit(1)
+			}
+
+			// Use the format of the path extension, unless the format is provided
+			format, _ := cmd.Flags().GetString("format")
+			if !cmd.Flags().Changed("format") && len(args) == 2 {
+				format = getExportFormat(args[1])
+			}
+
+			data, err := exportTranscript(c, format)
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(err.Error()))
+				os.Exit(1)
+			}
+
+			if len(args) == 1 {
+				fmt.Println(string(data))
+				return
+			}
+
+			if err = utils.WriteFileContents(args[1], data); err != nil {
+				fmt.Println(utils.ErrorMessage(fmt.Sprintf("Error writing transcript: %s", err.Error())))
+				os.Exit(1)
+			}
+
+			fmt.Println(utils.SuccessBox(fmt.Sprintf("Transcript exported to: %s", args[1])))
+		},
+	}
+
+	// removeSessionCmd removes a saved session
+	removeSessionCmd = &cobra.Command{
+		Use:     "remove [id]",
+		Aliases: []string{"rm"},
+		Short:   "Remove a saved session",
+		Long:    utils.InfoBox("Remove a saved chat session."),
+		Example: "oclai sessions rm 20250101-093000",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := removeChatSession(args[0]); err != nil {
+				fmt.Println(utils.ErrorMessage(err.Error()))
+				os.Exit(1)
+			}
+
+			fmt.Println(utils.SuccessBox(fmt.Sprintf("'%s' session removed successfully!", args[0])))
+		},
+	}
+
+	// renameSessionCmd renames a saved session
+	renameSessionCmd = &cobra.Command{
+		Use:     "rename [id] [title]",
+		Short:   "Rename a saved session",
+		Long:    utils.InfoBox("Change the title of a saved chat session."),
+		Example: `oclai sessions rename 20250101-093000 "Debugging the MCP timeout"`,
+		Args:    cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			title := strings.TrimSpace(strings.Join(args[1:], " "))
+			if title == "" {
+				fmt.Println(utils.ErrorMessage("Please provide the session title 😒"))
+				os.Exit(1)
+			}
+
+			c, err := loadChatSession(args[0])
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(err.Error()))
+				os.Exit(1)
+			}
+
+			c.Title = title
+			if err = saveChatSession(c); err != nil {
+				fmt.Println(utils.ErrorMessage(fmt.Sprintf("Error saving session: %s", err.Error())))
+				os.Exit(1)
+			}
+
+			fmt.Println(utils.SuccessBox(fmt.Sprintf("'%s' session renamed to: %s", c.ID, title)))
+		},
+	}
+)
+
+func init() {
+	// Add sub-commands to sessions root cmd
+	SessionsCmd.AddCommand(listSessionsCmd, showSessionCmd, exportSessionCmd, removeSessionCmd, renameSessionCmd)
+
+	// Register the transcript format flag of the export command
+	exportSessionCmd.Flags().StringP("format", "f", exportFormats[0], "Transcript format: md, json or html")
+}
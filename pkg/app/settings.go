@@ -0,0 +1,628 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/thejasmeetsingh/oclai/pkg/mcp"
+	"github.com/thejasmeetsingh/oclai/pkg/ollama"
+	"github.com/thejasmeetsingh/oclai/pkg/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// configKey describes a configuration key which can be set by the user
+type configKey struct {
+	description string
+	get         func(profile *Profile) any                 // Returns the value of the key
+	set         func(profile *Profile, value string) error // Validates the value and applies it to the profile
+	unset       func(profile, defaults *Profile)           // Resets the key to its default value
+}
+
+// configKeys is a map of the configuration keys which can be set by the user
+var configKeys = map[string]configKey{
+	"baseURL": {
+		description: "Ollama BaseURL",
+		get:         func(profile *Profile) any { return profile.BaseURL },
+		unset:       func(profile, defaults *Profile) { profile.BaseURL = defaults.BaseURL },
+		set: func(profile *Profile, value string) error {
+			baseURL, err := parseBaseURL(value)
+			if err != nil {
+				return err
+			}
+
+			profile.BaseURL = baseURL
+			return nil
+		},
+	},
+	"defaultModel": {
+		description: "Default model",
+		get:         func(profile *Profile) any { return profile.DefaultModel },
+		unset:       func(profile, defaults *Profile) { profile.DefaultModel = defaults.DefaultModel },
+		set: func(profile *Profile, value string) error {
+			model := strings.TrimSpace(value)
+			if model == "" {
+				return fmt.Errorf("model value cannot be empty. Please provide a valid model name")
+			}
+
+			profile.DefaultModel = model
+			return nil
+		},
+	},
+	"numCtx": {
+		description: "Context limit",
+		get:         func(profile *Profile) any { return profile.NumCtx },
+		unset:       func(profile, defaults *Profile) { profile.NumCtx = defaults.NumCtx },
+		set: func(profile *Profile, value string) error {
+			numCtx, err := parsePositiveInt(value)
+			if err != nil {
+				return err
+			}
+
+			profile.NumCtx = numCtx
+			return nil
+		},
+	},
+	"maxSteps": {
+		description: "Maximum tool-calling steps per request",
+		get:         func(profile *Profile) any { return profile.MaxSteps },
+		unset:       func(profile, defaults *Profile) { profile.MaxSteps = defaults.MaxSteps },
+		set: func(profile *Profile, value string) error {
+			maxSteps, err := parsePositiveInt(value)
+			if err != nil {
+				return err
+			}
+
+			profile.MaxSteps = maxSteps
+			return nil
+		},
+	},
+	"systemPrompt": {
+		description: "System prompt of the chat sessions",
+		get:         func(profile *Profile) any { return profile.SystemPrompt },
+		unset:       func(profile, defaults *Profile) { profile.SystemPrompt = defaults.SystemPrompt },
+		set: func(profile *Profile, value string) error {
+			profile.SystemPrompt = strings.TrimSpace(value)
+			return nil
+		},
+	},
+	"mcpServers": {
+		description: "Enabled MCP servers (comma separated), all of them if empty",
+		get:         func(profile *Profile) any { return profile.McpServers },
+		unset:       func(profile, defaults *Profile) { profile.McpServers = defaults.McpServers },
+		set: func(profile *Profile, value string) error {
+			profile.McpServers = parseList(value)
+			return nil
+		},
+	},
+	"toolPermissions": {
+		description: "Permission rules of the tool calls (comma separated 'server/tool=allow|ask|deny' glob patterns), the first match applies and the unmatched calls require an approval",
+		get:         func(profile *Profile) any { return profile.ToolPermissions },
+		unset:       func(profile, defaults *Profile) { profile.ToolPermissions = defaults.ToolPermissions },
+		set: func(profile *Profile, value string) error {
+			rules := parseList(value)
+			if _, err := parseToolRules(rules); err != nil {
+				return err
+			}
+
+			profile.ToolPermissions = rules
+			return nil
+		},
+	},
+}
+
+// parseList parses a comma separated list of values
+func parseList(value string) []string {
+	var values []string
+
+	for _, el := range strings.Split(value, ",") {
+		if el = strings.TrimSpace(el); el != "" {
+			values = append(values, el)
+		}
+	}
+
+	return values
+}
+
+// parseBaseURL validates the given Ollama BaseURL
+func parseBaseURL(value string) (string, error) {
+	value = strings.TrimSpace(value)
+
+	// Validate input
+	if value == "" {
+		return "", fmt.Errorf("'baseURL' cannot be empty. Please provide a valid URL")
+	}
+
+	// Parse URL
+	baseURL, err := url.Parse(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL format: %s. Please enter a valid URL", err.Error())
+	}
+
+	// Only accept absolute HTTP URLs, e.g. http://localhost:11434
+	if scheme := strings.ToLower(baseURL.Scheme); scheme != "http" && scheme != "https" {
+		return "", fmt.Errorf("invalid URL '%s': the scheme should be http or https, e.g. http://localhost:11434", value)
+	}
+
+	if baseURL.Hostname() == "" {
+		return "", fmt.Errorf("invalid URL '%s': the host is missing, e.g. http://localhost:11434", value)
+	}
+
+	return baseURL.String(), nil
+}
+
+// parsePositiveInt validates that the given value is a positive integer
+func parsePositiveInt(value string) (int, error) {
+	value = strings.TrimSpace(value)
+
+	// Validate input
+	if value == "" {
+		return 0, fmt.Errorf("value should not be empty")
+	}
+
+	// Convert to integer
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 1 {
+		return 0, fmt.Errorf("value should be a positive integer")
+	}
+
+	return number, nil
+}
+
+// checkModelExists verifies that the given model is available in the Ollama service
+func checkModelExists(baseURL, model string) error {
+	isExists, err := ollama.IsModelExists(baseURL, model, nil)
+	if err != nil {
+		return err
+	}
+
+	if !isExists {
+		return fmt.Errorf("'%s' model does not exists", model)
+	}
+
+	return nil
+}
+
+// optionalConfigKeys are the configuration keys which can be left empty
+var optionalConfigKeys = []string{"defaultModel", "systemPrompt", "mcpServers", "toolPermissions"}
+
+// validateProfile checks every required configuration key of the given profile
+func validateProfile(profile Profile) error {
+	for _, name := range getConfigKeyNames() {
+		if slices.Contains(optionalConfigKeys, name) {
+			continue
+		}
+
+		value := fmt.Sprint(configKeys[name].get(&profile))
+		if err := configKeys[name].set(&profile, value); err != nil {
+			return fmt.Errorf("invalid '%s' value: %s", name, err.Error())
+		}
+	}
+
+	if _, err := parseToolRules(profile.ToolPermissions); err != nil {
+		return fmt.Errorf("invalid 'toolPermissions' value: %s", err.Error())
+	}
+
+	return nil
+}
+
+// validateConfig checks every profile of the given configuration
+func validateConfig(config Config) error {
+	if _, exists := config.Profiles[config.ActiveProfile]; !exists {
+		return fmt.Errorf("'%s' active profile does not exists", config.ActiveProfile)
+	}
+
+	for name, profile := range config.Profiles {
+		if err := validateProfile(*profile); err != nil {
+			return fmt.Errorf("'%s' profile: %s", name, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// formatOutput formats the given value in the requested output format.
+// The text format of the value is returned as is, since it depends on the command.
+func formatOutput(value any, output string) (string, error) {
+	switch output {
+	case "json":
+		data, err := json.MarshalIndent(value, "", "  ")
+		return string(data), err
+	case "yaml":
+		data, err := yaml.Marshal(value)
+		return strings.TrimSpace(string(data)), err
+	default:
+		return "", fmt.Errorf("unsupported output format: '%s'. Supported formats: text, json, yaml", output)
+	}
+}
+
+// getConfigKeyNames returns the sorted names of the configuration keys
+func getConfigKeyNames() []string {
+	names := make([]string, 0, len(configKeys))
+	for name := range configKeys {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+var (
+	// ConfigCmd is the root command for configuration management
+	ConfigCmd = &cobra.Command{
+		Use:   "config",
+		Short: "Manage the configuration",
+		Long:  utils.InfoBox("Manage the configuration of the selected profile. Changes made with this command are persisted to the configuration file.\nUse the '--model', '--baseURL' and '--ctx' flags of a command to override a value for a single run instead."),
+		Example: `
+		oclai config set defaultModel qwen3:latest
+		oclai config set baseURL http://localhost:11434
+		oclai config set numCtx 16000
+		oclai config get defaultModel
+		oclai config list --output json
+		oclai config unset numCtx
+		oclai config edit
+	`,
+	}
+
+	// listConfigCmd lists the configuration values
+	listConfigCmd = &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the configuration values",
+		Long:    utils.InfoBox("List the configuration values in effect, including the values of the project configuration and the overrides set by environment variables."),
+		Example: `
+		oclai config list
+		oclai config ls --output yaml
+		oclai config list --show-origin
+	`,
+		Run: func(cmd *cobra.Command, args []string) {
+			output, _ := cmd.Flags().GetString("output")
+			showOrigin, _ := cmd.Flags().GetBool("show-origin")
+			names := getConfigKeyNames()
+
+			// Format the values as a markdown table by default
+			if output == "text" {
+				result := fmt.Sprintf("# ⚙️ Configuration (profile: *%s*)\n", selectedProfile)
+				if showOrigin {
+					result += "| Key | Value | Origin |\n| --- | --- | --- |\n"
+				} else {
+					result += "| Key | Value | Description |\n| --- | --- | --- |\n"
+				}
+
+				for _, name := range names {
+					lastColumn := configKeys[name].description
+					if showOrigin {
+						lastColumn = configOrigins[name]
+					}
+
+					result += fmt.Sprintf("| %s | %v | %s |\n", name, configKeys[name].get(&OclaiConfig), lastColumn)
+				}
+
+				md, err := utils.ToMarkDown(result, utils.TerminalWidth())
+				if err != nil {
+					fmt.Println(utils.ErrorMessage(fmt.Sprintf("Error caught while converting to markdown: %s", err)))
+					os.Exit(1)
+				}
+
+				fmt.Println(md)
+				return
+			}
+
+			values := make(map[string]any)
+			for _, name := range names {
+				if showOrigin {
+					values[name] = map[string]any{
+						"value":  configKeys[name].get(&OclaiConfig),
+						"origin": configOrigins[name],
+					}
+				} else {
+					values[name] = configKeys[name].get(&OclaiConfig)
+				}
+			}
+
+			result, err := formatOutput(values, output)
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(err.Error()))
+				os.Exit(1)
+			}
+
+			fmt.Println(result)
+		},
+	}
+
+	// getConfigCmd prints a configuration value
+	getConfigCmd = &cobra.Command{
+		Use:     "get [key]",
+		Short:   "Get a configuration value",
+		Long:    utils.InfoBox(fmt.Sprintf("Get a configuration value in effect.\nAvailable keys: %s", strings.Join(getConfigKeyNames(), ", "))),
+		Example: "oclai config get defaultModel",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			output, _ := cmd.Flags().GetString("output")
+
+			configKey, err := getConfigKey(args[0])
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(err.Error()))
+				os.Exit(1)
+			}
+
+			value := configKey.get(&OclaiConfig)
+
+			// Print the bare value by default, so it can be used in scripts
+			if output == "text" {
+				fmt.Println(value)
+				return
+			}
+
+			result, err := formatOutput(value, output)
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(err.Error()))
+				os.Exit(1)
+			}
+
+			fmt.Println(result)
+		},
+	}
+
+	// setConfigCmd persists a configuration value
+	setConfigCmd = &cobra.Command{
+		Use:     "set [key] [value]",
+		Short:   "Set a configuration value",
+		Long:    utils.InfoBox(fmt.Sprintf("Set a configuration value and persist it to the configuration file.\nAvailable keys: %s", strings.Join(getConfigKeyNames(), ", "))),
+		Example: "oclai config set defaultModel qwen3:latest",
+		Args:    cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			key, value := args[0], args[1]
+
+			configKey, err := getConfigKey(key)
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(err.Error()))
+				os.Exit(1)
+			}
+
+			// Validate the value before touching the configuration file
+			profile := *userConfig.Profiles[selectedProfile]
+			if err := configKey.set(&profile, value); err != nil {
+				fmt.Println(utils.ErrorMessage(err.Error()))
+				os.Exit(1)
+			}
+
+			// Check if the model exists in the configured Ollama service
+			if key == "defaultModel" {
+				if err := checkModelExists(profile.BaseURL, profile.DefaultModel); err != nil {
+					fmt.Println(utils.ErrorMessage(err.Error()))
+					os.Exit(1)
+				}
+			}
+
+			// Update the selected profile
+			err = updateProfile(func(p *Profile) {
+				configKey.set(p, value)
+			})
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(err.Error()))
+				os.Exit(1)
+			}
+
+			fmt.Println(utils.SuccessBox(fmt.Sprintf("%s updated successfully!", configKey.description)))
+		},
+	}
+
+	// unsetConfigCmd resets a configuration value to its default
+	unsetConfigCmd = &cobra.Command{
+		Use:     "unset [key]",
+		Short:   "Reset a configuration value to its default",
+		Long:    utils.InfoBox(fmt.Sprintf("Reset a configuration value to its default and persist it to the configuration file.\nAvailable keys: %s", strings.Join(getConfigKeyNames(), ", "))),
+		Example: "oclai config unset numCtx",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			configKey, err := getConfigKey(args[0])
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(err.Error()))
+				os.Exit(1)
+			}
+
+			defaults := defaultProfile()
+			err = updateProfile(func(p *Profile) {
+				configKey.unset(p, &defaults)
+			})
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(err.Error()))
+				os.Exit(1)
+			}
+
+			fmt.Println(utils.SuccessBox(fmt.Sprintf("%s reset to default!", configKey.description)))
+		},
+	}
+
+	// editConfigCmd opens the configuration file in the user's editor
+	editConfigCmd = &cobra.Command{
+		Use:     "edit",
+		Short:   "Edit the configuration file",
+		Long:    utils.InfoBox("Open the configuration file in your editor ($VISUAL or $EDITOR). The changes are validated once the editor is closed, and reverted if they are invalid."),
+		Example: "EDITOR=nano oclai config edit",
+		Run: func(cmd *cobra.Command, args []string) {
+			filePath := filepath.Join(rootPath, AppConfigFileName)
+
+			// Keep the current content to revert invalid changes
+			backup, err := utils.ReadConfig(filePath)
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(err.Error()))
+				os.Exit(1)
+			}
+
+			editorCmd := utils.GetEditorCmd(filePath)
+			editorCmd.Stdin = os.Stdin
+			editorCmd.Stdout = os.Stdout
+			editorCmd.Stderr = os.Stderr
+
+			if err = editorCmd.Run(); err != nil {
+				fmt.Println(utils.ErrorMessage(fmt.Sprintf("Error caught while running the editor: %s", err)))
+				os.Exit(1)
+			}
+
+			// Validate the edited configuration
+			err = LoadConfig(rootPath, selectedProfile)
+			if err == nil {
+				err = validateConfig(userConfig)
+			}
+
+			if err != nil {
+				utils.WriteFileContents(filePath, backup)
+				fmt.Println(utils.ErrorMessage(fmt.Sprintf("Invalid configuration, the changes were reverted: %s", err)))
+				os.Exit(1)
+			}
+
+			fmt.Println(utils.SuccessBox("Configuration updated successfully!"))
+		},
+	}
+
+	// pathConfigCmd prints the path of the configuration file
+	pathConfigCmd = &cobra.Command{
+		Use:     "path",
+		Short:   "Show the configuration file path",
+		Long:    utils.InfoBox("Show the path of the configuration file."),
+		Example: "oclai config path",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println(filepath.Join(rootPath, AppConfigFileName))
+		},
+	}
+)
+
+// getConfigKey returns the configuration key with the given name
+func getConfigKey(name string) (configKey, error) {
+	configKey, exists := configKeys[name]
+	if !exists {
+		return configKey, fmt.Errorf("unknown configuration key: '%s'. Available keys: %s", name, strings.Join(getConfigKeyNames(), ", "))
+	}
+
+	return configKey, nil
+}
+
+// addOverrideFlags registers the flags to override the configuration for a single run
+func addOverrideFlags(cmd *cobra.Command) {
+	cmd.Flags().String("model", "", "Model to use for this run")
+	cmd.Flags().String("baseURL", "", "Ollama BaseURL to use for this run")
+	cmd.Flags().String("ctx", "", "Context limit to use for this run")
+	cmd.Flags().String("system", "", "System prompt to use for this run")
+	cmd.Flags().String("system-file", "", "Read the system prompt to use for this run from a file")
+	cmd.Flags().String("persona", "", "Persona (from ~/.oclai/personas/<name>.md) to use as system prompt for this run")
+
+	cmd.Flags().String("mcp", "", "MCP servers (comma separated) whose tools are offered for this run, even if disabled")
+	cmd.Flags().Bool("no-tools", false, "Do not offer any tool to the model for this run")
+
+	cmd.MarkFlagsMutuallyExclusive("system", "system-file", "persona")
+	cmd.MarkFlagsMutuallyExclusive("mcp", "no-tools")
+}
+
+// getSystemPromptFlag returns the system prompt provided by the flags, if any
+func getSystemPromptFlag(cmd *cobra.Command) (string, string, error) {
+	if cmd.Flags().Changed("system") {
+		prompt, _ := cmd.Flags().GetString("system")
+		return prompt, "system", nil
+	}
+
+	if cmd.Flags().Changed("system-file") {
+		filePath, _ := cmd.Flags().GetString("system-file")
+		prompt, err := readPromptFile(filePath)
+		return prompt, "system-file", err
+	}
+
+	if cmd.Flags().Changed("persona") {
+		name, _ := cmd.Flags().GetString("persona")
+		prompt, err := loadPersona(name)
+		return prompt, "persona", err
+	}
+
+	return "", "", nil
+}
+
+// applyOverrideFlags applies the override flags which were provided to the effective configuration.
+// The flags take precedence over the environment variables and the configuration file.
+func applyOverrideFlags(cmd *cobra.Command) error {
+	flagKeys := []struct {
+		flag string
+		key  string
+	}{
+		{flag: "baseURL", key: "baseURL"},
+		{flag: "model", key: "defaultModel"},
+		{flag: "ctx", key: "numCtx"},
+	}
+
+	for _, flagKey := range flagKeys {
+		if !cmd.Flags().Changed(flagKey.flag) {
+			continue
+		}
+
+		value, _ := cmd.Flags().GetString(flagKey.flag)
+		if err := configKeys[flagKey.key].set(&OclaiConfig, value); err != nil {
+			return fmt.Errorf("invalid '--%s' flag: %s", flagKey.flag, err.Error())
+		}
+		configOrigins[flagKey.key] = fmt.Sprintf("flag (--%s)", flagKey.flag)
+	}
+
+	prompt, flag, err := getSystemPromptFlag(cmd)
+	if err != nil {
+		return fmt.Errorf("invalid '--%s' flag: %s", flag, err.Error())
+	}
+
+	if flag != "" {
+		OclaiConfig.SystemPrompt = prompt
+		configOrigins["systemPrompt"] = fmt.Sprintf("flag (--%s)", flag)
+	}
+
+	return nil
+}
+
+// prepareRun is the hook of the commands which talk to the model.
+// It applies the override flags, scopes the tools to the profile and the server flags,
+// then initializes the MCP servers in scope if needed: '--no-tools' starts no server at all.
+func prepareRun(cmd *cobra.Command, args []string) error {
+	if err := applyOverrideFlags(cmd); err != nil {
+		return err
+	}
+
+	// Only offer the tools of the servers enabled by the profile
+	mcp.SetServerScope(OclaiConfig.McpServers)
+
+	if err := applyServerFlags(cmd); err != nil {
+		return err
+	}
+
+	return InitializeMCP(cmd, args)
+}
+
+// applyServerFlags selects the MCP servers whose tools are offered for the run, if requested by the flags.
+// The selection takes precedence over the profile and the enabled servers.
+func applyServerFlags(cmd *cobra.Command) error {
+	if noTools, _ := cmd.Flags().GetBool("no-tools"); noTools {
+		return mcp.SetRunServers(nil)
+	}
+
+	if !cmd.Flags().Changed("mcp") {
+		return nil
+	}
+
+	value, _ := cmd.Flags().GetString("mcp")
+	if err := mcp.SetRunServers(parseList(value)); err != nil {
+		return fmt.Errorf("invalid '--mcp' flag: %s", err.Error())
+	}
+
+	return nil
+}
+
+func init() {
+	// Add sub-commands to config root cmd
+	ConfigCmd.AddCommand(listConfigCmd, getConfigCmd, setConfigCmd, unsetConfigCmd, editConfigCmd, pathConfigCmd)
+
+	// Register the output format flag of the read commands
+	listConfigCmd.Flags().StringP("output", "o", "text", "Output format: text, json or yaml")
+	listConfigCmd.Flags().Bool("show-origin", false, "Show where each value comes from")
+	getConfigCmd.Flags().StringP("output", "o", "text", "Output format: text, json or yaml")
+}
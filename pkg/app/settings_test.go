@@ -0,0 +1,41 @@
+package app
+
+import "testing"
+
+func TestParseBaseURL(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "http://localhost:11434", want: "http://localhost:11434"},
+		{value: "  https://ollama.example.com/ ", want: "https://ollama.example.com/"},
+		{value: "HTTP://127.0.0.1:11434", want: "http://127.0.0.1:11434"},
+		{value: "", wantErr: true},
+		{value: "not a url", wantErr: true},
+		{value: "localhost:11434", wantErr: true},
+		{value: "ftp://localhost:11434", wantErr: true},
+		{value: "http://", wantErr: true},
+		{value: "http://:11434", wantErr: true},
+		{value: "/api", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseBaseURL(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseBaseURL(%q) = %q, want an error", tt.value, got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parseBaseURL(%q) returned an error: %s", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseBaseURL(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/thejasmeetsingh/oclai/pkg/ollama"
+	"github.com/thejasmeetsingh/oclai/pkg/utils"
+)
+
+const (
+	// sessionsDirName is the name of the directory, under the application root, holding the saved chat sessions
+	sessionsDirName = "sessions"
+
+	// sessionIDFormat is the time layout used to generate the session IDs, so they sort chronologically
+	sessionIDFormat = "20060102-150405"
+
+	// maxTitleLength is the maximum length of a session title derived from its first message
+	maxTitleLength = 60
+)
+
+// chatSession represents a chat conversation saved to disk
+type chatSession struct {
+	ID        string           `json:"id"`        // Unique identifier of the session
+	Title     string           `json:"title"`     // Title of the session, the first user message by default
+	Model     string           `json:"model"`     // Model used last in the session
+	Profile   string           `json:"profile"`   // Profile the session was started with
+	ToolsUsed []string         `json:"toolsUsed"` // Names of the tools called during the session
+	Messages  []ollama.Message `json:"messages"`  // Conversation history, including the system prompt
+	CreatedAt time.Time        `json:"createdAt"` // Time the session was started
+	UpdatedAt time.Time        `json:"updatedAt"` // Time the session was last saved
+}
+
+// newChatSession creates a new chat session record
+func newChatSession() *chatSession {
+	now := time.Now()
+
+	// Avoid clashing with a session started within the same second
+	id := now.Format(sessionIDFormat)
+	for i := 2; ; i++ {
+		if _, err := os.Stat(filepath.Join(getSessionsDir(), id+".json")); os.IsNotExist(err) {
+			break
+		}
+		id = fmt.Sprintf("%s-%d", now.Format(sessionIDFormat), i)
+	}
+
+	return &chatSession{
+		ID:        id,
+		Profile:   selectedProfile,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// getSessionsDir returns the path of the directory holding the saved chat sessions
+func getSessionsDir() string {
+	return filepath.Join(rootPath, sessionsDirName)
+}
+
+// isValidFileName checks whether the given name only designates a file of its directory:
+// it cannot contain a path separator, nor start with a dot as ".." does
+func isValidFileName(name string) bool {
+	return name != "" && filepath.Base(name) == name && !strings.HasPrefix(name, ".")
+}
+
+// getSessionPath returns the file path of the given session, validating the session ID
+func getSessionPath(id string) (string, error) {
+	id = strings.TrimSpace(id)
+	if !isValidFileName(id) {
+		return "", fmt.Errorf("invalid session ID: '%s'", id)
+	}
+
+	return filepath.Join(getSessionsDir(), id+".json"), nil
+}
+
+// hasUserMessage reports whether the conversation contains at least one user message
+func (c *chatSession) hasUserMessage() bool {
+	return slices.ContainsFunc(c.Messages, func(message ollama.Message) bool {
+		return message.Role == ollama.UserRole
+	})
+}
+
+// displayTitle returns the title of the session, or a placeholder if it has none
+func (c *chatSession) displayTitle() string {
+	if c.Title == "" {
+		return "Chat session " + c.ID
+	}
+
+	return c.Title
+}
+
+// update records the given conversation in the session, deriving the title and the tools used
+func (c *chatSession) update(model string, messages []ollama.Message) {
+	c.Model = model
+	c.Messages = slices.Clone(messages)
+	c.UpdatedAt = time.Now()
+
+	for _, message := range messages {
+		// Use the first user message as the title, unless the session was renamed
+		if c.Title == "" && message.Role == ollama.UserRole {
+			c.Title = truncateTitle(message.Content)
+		}
+
+		if message.Role == ollama.ToolRole && message.ToolName != "" && !slices.Contains(c.ToolsUsed, message.ToolName) {
+			c.ToolsUsed = append(c.ToolsUsed, message.ToolName)
+		}
+	}
+}
+
+// truncateTitle shortens the given text to a single line title
+func truncateTitle(text string) string {
+	title := strings.Join(strings.Fields(text), " ")
+
+	if runes := []rune(title); len(runes) > maxTitleLength {
+		title = string(runes[:maxTitleLength-1]) + "…"
+	}
+
+	return title
+}
+
+// saveChatSession writes the given session to the sessions directory
+func saveChatSession(c *chatSession) error {
+	filePath, err := getSessionPath(c.ID)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(getSessionsDir(), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return utils.WriteFileContents(filePath, data)
+}
+
+// loadChatSession reads the session with the given ID
+func loadChatSession(id string) (*chatSession, error) {
+	filePath, err := getSessionPath(id)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("'%s' session does not exists", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var c chatSession
+	if err = json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse '%s' session: %s", id, err.Error())
+	}
+
+	return &c, nil
+}
+
+// listChatSessions returns the saved sessions, the most recently updated first.
+// The sessions which cannot be read are reported on the standard error and left out.
+func listChatSessions() ([]*chatSession, error) {
+	entries, err := os.ReadDir(getSessionsDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []*chatSession
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		// Skip the unreadable sessions, so one corrupt file does not hide the others
+		c, err := loadChatSession(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, utils.ErrorMessage(fmt.Sprintf("Skipping '%s': %s", entry.Name(), err.Error())))
+			continue
+		}
+		sessions = append(sessions, c)
+	}
+
+	slices.SortFunc(sessions, func(a, b *chatSession) int {
+		return b.UpdatedAt.Compare(a.UpdatedAt)
+	})
+
+	return sessions, nil
+}
+
+// lastChatSession returns the most recently updated session
+func lastChatSession() (*chatSession, error) {
+	sessions, err := listChatSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sessions) == 0 {
+		return nil, fmt.Errorf("no saved sessions found")
+	}
+
+	return sessions[0], nil
+}
+
+// removeChatSession deletes the session with the given ID
+func removeChatSession(id string) error {
+	filePath, err := getSessionPath(id)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(filePath)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("'%s' session does not exists", id)
+	}
+
+	return err
+}
@@ -0,0 +1,44 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestListChatSessionsSkipsUnreadableFiles(t *testing.T) {
+	rootPath = t.TempDir()
+
+	now := time.Now()
+	for i, id := range []string{"20250101-090000", "20250102-090000"} {
+		c := &chatSession{ID: id, UpdatedAt: now.Add(time.Duration(i) * time.Hour)}
+		if err := saveChatSession(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(getSessionsDir(), "corrupt.json"), []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := listChatSessions()
+	if err != nil {
+		t.Fatalf("listChatSessions returned an error: %s", err)
+	}
+
+	if len(sessions) != 2 {
+		t.Fatalf("got %d sessions, want 2", len(sessions))
+	}
+	if sessions[0].ID != "20250102-090000" {
+		t.Errorf("got %q first, want the most recently updated session", sessions[0].ID)
+	}
+
+	last, err := lastChatSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.ID != "20250102-090000" {
+		t.Errorf("lastChatSession() = %q, want %q", last.ID, "20250102-090000")
+	}
+}
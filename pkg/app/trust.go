@@ -0,0 +1,172 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/thejasmeetsingh/oclai/pkg/utils"
+)
+
+// trustedProjectKeys are the settings which are only applied from the configuration of a trusted project
+var trustedProjectKeys = []string{"baseURL", "toolPermissions"}
+
+// resolveProjectDir returns the absolute path of the given project directory, with the symbolic links resolved
+func resolveProjectDir(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		return resolved, nil
+	}
+
+	return dir, nil
+}
+
+// isTrustedProject checks whether the project of the given project-local configuration is trusted
+func isTrustedProject(projectPath string) bool {
+	dir, err := resolveProjectDir(filepath.Dir(projectPath))
+	if err != nil {
+		return false
+	}
+
+	return slices.Contains(userConfig.TrustedProjects, dir)
+}
+
+// IsProjectTrusted tells whether the project-local configuration of the current run is trusted
+func IsProjectTrusted() bool {
+	return projectTrusted
+}
+
+// getProjectDir returns the project directory given in the command arguments,
+// or the directory of the project-local configuration found from the working directory
+func getProjectDir(args []string) (string, error) {
+	if len(args) != 0 {
+		return resolveProjectDir(strings.TrimSpace(args[0]))
+	}
+
+	projectPath, err := utils.FindProjectConfig()
+	if err != nil {
+		return "", err
+	}
+
+	if projectPath == "" {
+		return "", fmt.Errorf("no project configuration found from the current directory, please provide the project path")
+	}
+
+	return resolveProjectDir(filepath.Dir(projectPath))
+}
+
+var (
+	// TrustCmd trusts the configuration of a project
+	TrustCmd = &cobra.Command{
+		Use:   "trust [path]",
+		Short: "Trust the configuration of a project",
+		Long:  utils.InfoBox(fmt.Sprintf("Trust the project-local configuration of a project, the current one by default.\nThe MCP servers and the %s settings of a project configuration are ignored until the project is trusted, since they can run commands on your machine or send your prompts elsewhere.", strings.Join(trustedProjectKeys, ", "))),
+		Example: `
+		oclai trust
+		oclai trust ~/code/my-project
+		oclai trust ls
+		oclai trust rm ~/code/my-project
+	`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dir, err := getProjectDir(args)
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(err.Error()))
+				os.Exit(1)
+			}
+
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+				fmt.Println(utils.ErrorMessage(fmt.Sprintf("'%s' is not a directory", dir)))
+				os.Exit(1)
+			}
+
+			if slices.Contains(userConfig.TrustedProjects, dir) {
+				fmt.Println(utils.InfoBox(fmt.Sprintf("'%s' project is already trusted", dir)))
+				return
+			}
+
+			err = UpdateConfig(rootPath, func(config *Config) {
+				config.TrustedProjects = append(config.TrustedProjects, dir)
+			})
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(fmt.Sprintf("Error caught while updating the configuration: %s", err)))
+				os.Exit(1)
+			}
+
+			fmt.Println(utils.SuccessBox(fmt.Sprintf("'%s' project is now trusted!", dir)))
+		},
+	}
+
+	// listTrustedCmd lists the trusted projects
+	listTrustedCmd = &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the trusted projects",
+		Long:    utils.InfoBox("List the directories of the trusted projects."),
+		Example: "oclai trust ls",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(userConfig.TrustedProjects) == 0 {
+				fmt.Println(utils.ErrorBox("No project is trusted 🔒"))
+				return
+			}
+
+			result := "# Trusted Projects\n"
+			for _, dir := range userConfig.TrustedProjects {
+				result += fmt.Sprintf("- %s\n", dir)
+			}
+
+			md, err := utils.ToMarkDown(result, utils.TerminalWidth())
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(fmt.Sprintf("Error caught while converting to markdown: %s", err)))
+				os.Exit(1)
+			}
+
+			fmt.Println(md)
+		},
+	}
+
+	// removeTrustedCmd revokes the trust of a project
+	removeTrustedCmd = &cobra.Command{
+		Use:     "remove [path]",
+		Aliases: []string{"rm"},
+		Short:   "Stop trusting a project",
+		Long:    utils.InfoBox("Stop trusting the configuration of a project, the current one by default."),
+		Example: "oclai trust rm ~/code/my-project",
+		Args:    cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dir, err := getProjectDir(args)
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(err.Error()))
+				os.Exit(1)
+			}
+
+			idx := slices.Index(userConfig.TrustedProjects, dir)
+			if idx == -1 {
+				fmt.Println(utils.ErrorMessage(fmt.Sprintf("'%s' project is not trusted", dir)))
+				os.Exit(1)
+			}
+
+			err = UpdateConfig(rootPath, func(config *Config) {
+				config.TrustedProjects = slices.Delete(config.TrustedProjects, idx, idx+1)
+			})
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(fmt.Sprintf("Error caught while updating the configuration: %s", err)))
+				os.Exit(1)
+			}
+
+			fmt.Println(utils.SuccessBox(fmt.Sprintf("'%s' project is no longer trusted!", dir)))
+		},
+	}
+)
+
+func init() {
+	// Add sub-commands to trust root cmd
+	TrustCmd.AddCommand(listTrustedCmd, removeTrustedCmd)
+}
@@ -0,0 +1,106 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// setupProject creates a project with the given oclai.json content and moves into one of its sub-directories
+func setupProject(t *testing.T, content string) string {
+	t.Helper()
+
+	t.Setenv("HOME", t.TempDir())
+
+	dir, err := resolveProjectDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "oclai.json"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(sub)
+
+	return dir
+}
+
+func TestLoadProjectConfigIgnoresSensitiveKeysUntilTrusted(t *testing.T) {
+	dir := setupProject(t, `{"config": {"baseURL": "http://remote:11434", "defaultModel": "qwen3:8b", "toolPermissions": ["*=allow"]}}`)
+
+	tests := []struct {
+		name    string
+		trusted []string
+		want    map[string]string
+		wantOK  bool
+	}{
+		{
+			name: "untrusted",
+			want: map[string]string{"defaultModel": "qwen3:8b"},
+		},
+		{
+			name:    "other project trusted",
+			trusted: []string{filepath.Join(dir, "other")},
+			want:    map[string]string{"defaultModel": "qwen3:8b"},
+		},
+		{
+			name:    "trusted",
+			trusted: []string{dir},
+			want: map[string]string{
+				"baseURL":         "http://remote:11434",
+				"defaultModel":    "qwen3:8b",
+				"toolPermissions": "*=allow",
+			},
+			wantOK: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userConfig = Config{TrustedProjects: tt.trusted}
+			projectSettings = make(map[string]string)
+
+			if err := loadProjectConfig(); err != nil {
+				t.Fatal(err)
+			}
+
+			if projectTrusted != tt.wantOK {
+				t.Errorf("projectTrusted = %t, want %t", projectTrusted, tt.wantOK)
+			}
+
+			if len(projectSettings) != len(tt.want) {
+				t.Fatalf("projectSettings = %v, want %v", projectSettings, tt.want)
+			}
+			for key, value := range tt.want {
+				if projectSettings[key] != value {
+					t.Errorf("projectSettings[%q] = %q, want %q", key, projectSettings[key], value)
+				}
+			}
+		})
+	}
+}
+
+func TestGetProjectDir(t *testing.T) {
+	dir := setupProject(t, `{"config": {}}`)
+
+	got, err := getProjectDir(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != dir {
+		t.Errorf("getProjectDir() = %q, want %q", got, dir)
+	}
+
+	got, err = getProjectDir([]string{".."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != dir {
+		t.Errorf("getProjectDir(\"..\") = %q, want %q", got, dir)
+	}
+}
@@ -2,10 +2,7 @@ package cmd
 
 import (
 	"fmt"
-	"net/url"
 	"os"
-	"strconv"
-	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/thejasmeetsingh/oclai/pkg/app"
@@ -14,9 +11,6 @@ import (
 	"github.com/thejasmeetsingh/oclai/pkg/utils"
 )
 
-// rootPath stores the application root directory path
-var rootPath = ""
-
 var (
 	// rootCmd represents the base command when called without any subcommands
 	rootCmd = &cobra.Command{
@@ -25,21 +19,37 @@ var (
 		Long:    utils.InfoBox("An AI powered terminal assistant similar to Claude Code and Gemini CLI, but runs entirely offline using local models.\nNo API keys, no subscriptions, no data leaving your machine."),
 		Example: `oclai q "Tell me about the roman empire"`,
 		Version: "1.0.7",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// The shell completion scripts do not depend on the configuration
+			if cmd.HasParent() && cmd.Parent().Name() == "completion" {
+				return nil
+			}
+
+			// Get application root directory, it is created on the first run
+			rootPath, err := utils.GetAppRootDir()
+			if err != nil {
+				return fmt.Errorf("failed to retrieve the application directory: %s", err.Error())
+			}
+
+			// Load App configuration file with the selected profile
+			profile, _ := cmd.Flags().GetString("profile")
+			if err := app.LoadConfig(rootPath, profile); err != nil {
+				return fmt.Errorf("failed to load configuration: %s", err.Error())
+			}
+
+			// Load MCP servers configuration file, the servers are only started by the commands which need them
+			if err := mcp.LoadConfig(rootPath, app.IsProjectTrusted()); err != nil {
+				return fmt.Errorf("failed to load MCP servers: %s", err.Error())
+			}
+
+			return nil
+		},
 		Run: func(cmd *cobra.Command, args []string) {
 			// If there are arguments, do nothing (handled by other commands)
 			if len(args) != 0 {
 				return
 			}
 
-			// Check if any global flags have been changed
-			globalCmds := []string{"baseURL", "model", "ctx"}
-			for _, gloglobalCmd := range globalCmds {
-				if cmd.Flags().Lookup(gloglobalCmd).Changed {
-					return
-				}
-			}
-
-			// If no flags changed, show help
 			cmd.Help()
 		},
 	}
@@ -77,104 +87,13 @@ var (
 	}
 )
 
-// setBaseURL updates the base URL configuration
-func setBaseURL(arg string) error {
-	arg = strings.TrimSpace(arg)
-
-	// Validate input
-	if arg == "" {
-		return fmt.Errorf("✗ 'baseURL' cannot be empty. Please provide a valid URL")
-	}
-
-	// Parse URL
-	baseURL, err := url.Parse(strings.TrimSpace(arg))
-	if err != nil {
-		return fmt.Errorf("✗ invalid URL format: %s. Please enter a valid URL", err.Error())
-	}
-
-	// Update configuration
-	app.OclaiConfig.BaseURL = baseURL.String()
-	if err = app.UpdateConfig(rootPath); err != nil {
-		return err
-	}
-
-	fmt.Println(utils.SuccessBox("BaseURL updated successfully!"))
-
-	return nil
-}
-
-// setDefaultModel updates the default model configuration
-func setDefaultModel(arg string) error {
-	arg = strings.TrimSpace(arg)
-
-	// Validate input
-	if arg == "" {
-		return fmt.Errorf("✗ model value cannot be empty. Please provide a valid model name")
-	}
-
-	model := strings.TrimSpace(arg)
-
-	// Check if the model exists
-	isExists, err := ollama.IsModelExists(app.OclaiConfig.BaseURL, model, nil)
-	if err != nil {
-		return err
-	}
-
-	if !isExists {
-		return fmt.Errorf("'%s' model does not exists", model)
-	}
-
-	// Update configuration
-	app.OclaiConfig.DefaultModel = strings.TrimSpace(arg)
-	if err := app.UpdateConfig(rootPath); err != nil {
-		return err
-	}
-
-	fmt.Println(utils.SuccessBox("Default Model updated successfully!"))
-
-	return nil
-}
-
-// setNumCtx updates the context limit configuration
-func setNumCtx(arg string) error {
-	arg = strings.TrimSpace(arg)
-
-	// Validate input
-	if arg == "" {
-		return fmt.Errorf("✗ 'num_ctx' value should not be empty")
-	}
-
-	// Convert to integer
-	numCtx, err := strconv.Atoi(arg)
-	if err != nil {
-		return fmt.Errorf("✗ value should be a valid integer")
-	}
-
-	// Update configuration
-	app.OclaiConfig.NumCtx = numCtx
-	if err := app.UpdateConfig(rootPath); err != nil {
-		return err
-	}
-
-	fmt.Println(utils.SuccessBox("NumCtx value updated successfully!"))
-
-	return nil
-}
-
 func init() {
-	// Get application root directory
-	_rootPath, err := utils.GetAppRootDir()
-	if err != nil {
-		fmt.Println(utils.ErrorMessage(fmt.Sprintf("Error caught while retreiving root path: %s", err.Error())))
-		os.Exit(1)
-	}
-
-	rootPath = _rootPath
+	// Run the persistent hooks of the parent commands as well, so that the configuration
+	// is always loaded before a sub-command runs its own hooks
+	cobra.EnableTraverseRunHooks = true
 
-	// Register command flags
-	rootCmd.PersistentFlags().Func("baseURL", "Set Ollama BaseURL", setBaseURL)
-	rootCmd.PersistentFlags().Func("model", "Set Default Model", setDefaultModel)
-	rootCmd.PersistentFlags().Func("ctx", "Set Context Limit", setNumCtx)
+	// Register the profile selection flag
+	rootCmd.PersistentFlags().String("profile", "", "Configuration profile to use (defaults to $OCLAI_PROFILE or the active profile)")
 
 	// Update version display template
 	rootCmd.SetVersionTemplate(`Oclai version is {{printf "%s\n" .Version}}`)
@@ -185,6 +104,10 @@ func init() {
 		statusCmd,
 		app.Query,
 		app.Chat,
+		app.ConfigCmd,
+		app.ProfileCmd,
+		app.SessionsCmd,
+		app.TrustCmd,
 		mcp.McpRootCmd,
 	)
 }
@@ -0,0 +1,229 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	goMCP "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thejasmeetsingh/oclai/pkg/ollama"
+	"github.com/thejasmeetsingh/oclai/pkg/utils"
+)
+
+// findTool returns the cached tool of the given server with the given name, case-insensitively
+func findTool(server *McpServer, name string) (*ollama.Tool, error) {
+	for idx := range server.Tools {
+		if strings.EqualFold(server.Tools[idx].Function.Name, name) {
+			return &server.Tools[idx], nil
+		}
+	}
+
+	return nil, fmt.Errorf("'%s' tool does not exists on '%s' server, run `oclai mcp refresh` to discover its tools", name, server.Name)
+}
+
+// getPropertyTypes returns the JSON types accepted by a property of an input schema, if any
+func getPropertyTypes(property any) []string {
+	schema, ok := property.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	switch value := schema["type"].(type) {
+	case string:
+		return []string{value}
+	case []any:
+		var types []string
+		for _, item := range value {
+			if name, ok := item.(string); ok {
+				types = append(types, name)
+			}
+		}
+		return types
+	}
+
+	return nil
+}
+
+// getPropertySchema returns the schema of the given property of an input schema. A property which is not listed
+// is only accepted if the "additionalProperties" keyword is true or a schema, it is rejected if the keyword is absent.
+func getPropertySchema(schema ollama.Parameter, name string) (any, bool) {
+	if property, exists := schema.Properties[name]; exists {
+		return property, true
+	}
+
+	switch value := schema.AdditionalProperties.(type) {
+	case bool:
+		return nil, value
+	case map[string]any:
+		return value, true
+	}
+
+	return nil, false
+}
+
+// parseToolArgs builds the arguments of a tool call from a JSON object, or from "key=value" pairs.
+// A value is kept as a string if the property expects one, otherwise it is decoded as JSON when possible.
+func parseToolArgs(tool *ollama.Tool, pairs []string, rawJSON string) (map[string]any, error) {
+	args := make(map[string]any)
+
+	if rawJSON != "" {
+		if err := json.Unmarshal([]byte(rawJSON), &args); err != nil {
+			return nil, fmt.Errorf("'--json' should be a JSON object: %s", err)
+		}
+		return args, nil
+	}
+
+	for _, pair := range pairs {
+		key, value, found := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid argument '%s', expected 'key=value'", pair)
+		}
+
+		property, _ := getPropertySchema(tool.Function.Parameter, key)
+		types := getPropertyTypes(property)
+
+		var decoded any
+		if !slices.Contains(types, "string") && json.Unmarshal([]byte(value), &decoded) == nil {
+			args[key] = decoded
+		} else {
+			args[key] = value
+		}
+	}
+
+	return args, nil
+}
+
+// matchesType checks whether the given decoded JSON value is of the given JSON schema type
+func matchesType(value any, schemaType string) bool {
+	switch schemaType {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "null":
+		return value == nil
+	}
+
+	// Accept the types which are not checked
+	return true
+}
+
+// validateToolArgs checks the given arguments against the cached input schema of the tool:
+// the required properties must be provided, the unknown ones are rejected unless the schema allows them,
+// and the values must be of the expected type
+func validateToolArgs(tool *ollama.Tool, args map[string]any) error {
+	schema := tool.Function.Parameter
+
+	for _, name := range schema.Required {
+		if _, exists := args[name]; !exists {
+			return fmt.Errorf("'%s' argument is required by '%s' tool", name, tool.Function.Name)
+		}
+	}
+
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		property, exists := getPropertySchema(schema, name)
+		if !exists {
+			accepted := make([]string, 0, len(schema.Properties))
+			for key := range schema.Properties {
+				accepted = append(accepted, key)
+			}
+			slices.Sort(accepted)
+
+			if len(accepted) == 0 {
+				return fmt.Errorf("'%s' tool accepts no arguments, got '%s'", tool.Function.Name, name)
+			}
+			return fmt.Errorf("unknown argument '%s' for '%s' tool, expected one of: %s", name, tool.Function.Name, strings.Join(accepted, ", "))
+		}
+
+		types := getPropertyTypes(property)
+		if len(types) == 0 {
+			continue
+		}
+
+		if !slices.ContainsFunc(types, func(schemaType string) bool { return matchesType(args[name], schemaType) }) {
+			return fmt.Errorf("'%s' argument should be of type %s", name, strings.Join(types, " or "))
+		}
+	}
+
+	return nil
+}
+
+// callServerTool connects to the given server and calls one of its tools, returning the raw result
+func callServerTool(ctx context.Context, server McpServer, params *goMCP.CallToolParams) (*goMCP.CallToolResult, error) {
+	session, err := createSession(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	return session.CallTool(ctx, params)
+}
+
+// formatContent renders a content block returned by a tool
+func formatContent(content goMCP.Content) string {
+	switch block := content.(type) {
+	case *goMCP.TextContent:
+		return "**Text**\n" + utils.CodeBlock(block.Text) + "\n"
+	case *goMCP.ImageContent:
+		return fmt.Sprintf("**Image** %s, %d bytes\n", block.MIMEType, len(block.Data))
+	case *goMCP.AudioContent:
+		return fmt.Sprintf("**Audio** %s, %d bytes\n", block.MIMEType, len(block.Data))
+	case *goMCP.ResourceLink:
+		return fmt.Sprintf("**Resource link** %s (%s)\n", block.URI, block.Name)
+	case *goMCP.EmbeddedResource:
+		if block.Resource == nil {
+			return "**Resource** *empty*\n"
+		}
+		if block.Resource.Text != "" {
+			return fmt.Sprintf("**Resource** %s\n%s\n", block.Resource.URI, utils.CodeBlock(block.Resource.Text))
+		}
+		return fmt.Sprintf("**Resource** %s, %d bytes\n", block.Resource.URI, len(block.Resource.Blob))
+	}
+
+	return "**Unknown content**\n" + jsonBlock(content)
+}
+
+// formatCallResult renders every content block returned by a tool call, along with its error state and structured content
+func formatCallResult(server McpServer, toolName string, result *goMCP.CallToolResult) string {
+	var md strings.Builder
+
+	fmt.Fprintf(&md, "# %s/%s\n", server.Name, toolName)
+	fmt.Fprintf(&md, "- **IsError:** %t\n", result.IsError)
+	fmt.Fprintf(&md, "- **Content blocks:** %d\n", len(result.Content))
+
+	for idx, content := range result.Content {
+		fmt.Fprintf(&md, "\n## Content %d\n", idx+1)
+		md.WriteString(formatContent(content))
+	}
+
+	if result.StructuredContent != nil {
+		md.WriteString("\n## Structured content\n")
+		md.WriteString(jsonBlock(result.StructuredContent))
+	}
+
+	return md.String()
+}
@@ -0,0 +1,282 @@
+package mcp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/thejasmeetsingh/oclai/pkg/ollama"
+)
+
+// testTool returns a tool whose input schema covers the JSON types
+func testTool() *ollama.Tool {
+	return &ollama.Tool{
+		ToolType: "function",
+		Function: ollama.Function{
+			Name: "search",
+			Parameter: ollama.Parameter{
+				ParameterType: "object",
+				Properties: map[string]any{
+					"query":   map[string]any{"type": "string"},
+					"limit":   map[string]any{"type": "integer"},
+					"score":   map[string]any{"type": "number"},
+					"exact":   map[string]any{"type": "boolean"},
+					"tags":    map[string]any{"type": "array"},
+					"filters": map[string]any{"type": "object"},
+					"cursor":  map[string]any{"type": []any{"string", "null"}},
+					"extra":   map[string]any{"description": "Untyped property"},
+				},
+				Required: []string{"query"},
+			},
+		},
+	}
+}
+
+func TestValidateToolArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    map[string]any
+		wantErr string // Expected part of the error, none if empty
+	}{
+		{
+			name: "required only",
+			args: map[string]any{"query": "go"},
+		},
+		{
+			name: "all the types",
+			args: map[string]any{
+				"query":   "go",
+				"limit":   float64(10),
+				"score":   0.5,
+				"exact":   true,
+				"tags":    []any{"a"},
+				"filters": map[string]any{"lang": "en"},
+				"cursor":  nil,
+				"extra":   []any{1, "two"},
+			},
+		},
+		{
+			name:    "missing required argument",
+			args:    map[string]any{"limit": float64(10)},
+			wantErr: "'query' argument is required by 'search' tool",
+		},
+		{
+			name:    "string instead of integer",
+			args:    map[string]any{"query": "go", "limit": "10"},
+			wantErr: "'limit' argument should be of type integer",
+		},
+		{
+			name:    "float instead of integer",
+			args:    map[string]any{"query": "go", "limit": 1.5},
+			wantErr: "'limit' argument should be of type integer",
+		},
+		{
+			name: "integer as number",
+			args: map[string]any{"query": "go", "score": float64(3)},
+		},
+		{
+			name:    "number instead of string",
+			args:    map[string]any{"query": float64(42)},
+			wantErr: "'query' argument should be of type string",
+		},
+		{
+			name:    "string instead of boolean",
+			args:    map[string]any{"query": "go", "exact": "true"},
+			wantErr: "'exact' argument should be of type boolean",
+		},
+		{
+			name:    "object instead of array",
+			args:    map[string]any{"query": "go", "tags": map[string]any{}},
+			wantErr: "'tags' argument should be of type array",
+		},
+		{
+			name: "second of several types",
+			args: map[string]any{"query": "go", "cursor": nil},
+		},
+		{
+			name:    "none of several types",
+			args:    map[string]any{"query": "go", "cursor": float64(1)},
+			wantErr: "'cursor' argument should be of type string or null",
+		},
+		{
+			name:    "unknown argument",
+			args:    map[string]any{"query": "go", "page": float64(2)},
+			wantErr: "unknown argument 'page' for 'search' tool, expected one of: cursor, exact, extra, filters, limit, query, score, tags",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateToolArgs(testTool(), tt.args)
+
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateToolArgsWithoutProperties(t *testing.T) {
+	tool := &ollama.Tool{Function: ollama.Function{Name: "ping"}}
+
+	if err := validateToolArgs(tool, map[string]any{}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	err := validateToolArgs(tool, map[string]any{"host": "localhost"})
+	if err == nil || err.Error() != "'ping' tool accepts no arguments, got 'host'" {
+		t.Errorf("error = %v, want the tool to accept no arguments", err)
+	}
+}
+
+func TestValidateToolArgsWithAdditionalProperties(t *testing.T) {
+	tests := []struct {
+		name       string
+		additional any
+		args       map[string]any
+		wantErr    string // Expected part of the error, none if empty
+	}{
+		{
+			name:       "open schema",
+			additional: true,
+			args:       map[string]any{"query": "go", "page": float64(2), "debug": true},
+		},
+		{
+			name:       "open schema still checks the listed properties",
+			additional: true,
+			args:       map[string]any{"query": float64(1), "page": float64(2)},
+			wantErr:    "'query' argument should be of type string",
+		},
+		{
+			name:       "typed additional properties",
+			additional: map[string]any{"type": "integer"},
+			args:       map[string]any{"query": "go", "page": float64(2)},
+		},
+		{
+			name:       "additional property of the wrong type",
+			additional: map[string]any{"type": "integer"},
+			args:       map[string]any{"query": "go", "page": "two"},
+			wantErr:    "'page' argument should be of type integer",
+		},
+		{
+			name:       "closed schema",
+			additional: false,
+			args:       map[string]any{"query": "go", "page": float64(2)},
+			wantErr:    "unknown argument 'page' for 'search' tool",
+		},
+		{
+			name:    "absent keyword",
+			args:    map[string]any{"query": "go", "page": float64(2)},
+			wantErr: "unknown argument 'page' for 'search' tool",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := testTool()
+			tool.Function.Parameter.AdditionalProperties = tt.additional
+
+			err := validateToolArgs(tool, tt.args)
+
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseToolArgsWithAdditionalProperties(t *testing.T) {
+	tool := testTool()
+	tool.Function.Parameter.AdditionalProperties = map[string]any{"type": "string"}
+
+	got, err := parseToolArgs(tool, []string{"page=2", "limit=3"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]any{"page": "2", "limit": float64(3)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseToolArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestParseToolArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		pairs   []string
+		rawJSON string
+		want    map[string]any
+		wantErr bool
+	}{
+		{
+			name:  "string property is kept as is",
+			pairs: []string{"query=42"},
+			want:  map[string]any{"query": "42"},
+		},
+		{
+			name:  "typed properties are decoded",
+			pairs: []string{"limit=10", "exact=true", `tags=["a","b"]`},
+			want:  map[string]any{"limit": float64(10), "exact": true, "tags": []any{"a", "b"}},
+		},
+		{
+			name:  "invalid JSON is kept as a string",
+			pairs: []string{"extra=not json"},
+			want:  map[string]any{"extra": "not json"},
+		},
+		{
+			name:  "value containing an equal sign",
+			pairs: []string{"query=a=b"},
+			want:  map[string]any{"query": "a=b"},
+		},
+		{
+			name:    "missing value",
+			pairs:   []string{"query"},
+			wantErr: true,
+		},
+		{
+			name:    "JSON object",
+			rawJSON: `{"query": "go", "limit": 5}`,
+			want:    map[string]any{"query": "go", "limit": float64(5)},
+		},
+		{
+			name:    "JSON array",
+			rawJSON: `["go"]`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseToolArgs(testTool(), tt.pairs, tt.rawJSON)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseToolArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,17 +1,16 @@
 package mcp
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
 
+	goMCP "github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/spf13/cobra"
 	"github.com/thejasmeetsingh/oclai/pkg/utils"
 )
 
-// rootPath stores the root directory path for MCP server configurations
-var rootPath = ""
-
 var (
 	// McpRootCmd is the root command for MCP server management
 	McpRootCmd = &cobra.Command{
@@ -22,6 +21,11 @@ var (
 		oclai mcp ls
 		oclai mcp add --name everything --cmd npx --args '-y @modelcontextprotocol/server-everything'
 		oclai mcp rm everything
+		oclai mcp disable fetch
+		oclai mcp refresh
+		oclai mcp tools filesystem
+		oclai mcp inspect filesystem read_file
+		oclai mcp call filesystem list_directory --arg path=.
 	`,
 	}
 
@@ -50,7 +54,7 @@ var (
 			}
 
 			// Convert the result to markdown format
-			md, err := utils.ToMarkDown(result)
+			md, err := utils.ToMarkDown(result, utils.TerminalWidth())
 			if err != nil {
 				fmt.Println(utils.ErrorMessage(fmt.Sprintf("Error caught while converting to markdown: %s", err)))
 				os.Exit(1)
@@ -88,6 +92,236 @@ var (
 		},
 	}
 
+	// enableServerCmd enables a MCP server, its tools are offered to the model again
+	enableServerCmd = &cobra.Command{
+		Use:     "enable [name]",
+		Short:   "Enable a MCP server",
+		Long:    utils.InfoBox("Enable a MCP server. The tools of the server are offered to the model again."),
+		Example: "oclai mcp enable fetch",
+		Run: func(cmd *cobra.Command, args []string) {
+			setServerState(cmd, args, true)
+		},
+	}
+
+	// disableServerCmd disables a MCP server without removing its configuration
+	disableServerCmd = &cobra.Command{
+		Use:     "disable [name]",
+		Short:   "Disable a MCP server",
+		Long:    utils.InfoBox("Disable a MCP server. The server is not started and its tools are not offered to the model, while its configuration is kept."),
+		Example: "oclai mcp disable fetch",
+		Run: func(cmd *cobra.Command, args []string) {
+			setServerState(cmd, args, false)
+		},
+	}
+
+	// listToolsCmd lists the tools discovered on the MCP servers
+	listToolsCmd = &cobra.Command{
+		Use:   "tools [server]",
+		Short: "List the tools of the MCP servers",
+		Long:  utils.InfoBox("List the tools of the MCP servers. This command displays the name and description of the tools discovered on every server, or on the given server."),
+		Example: `
+		oclai mcp tools
+		oclai mcp tools filesystem
+		`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			servers := getServers()
+
+			// Only list the tools of the given server, if any
+			if len(args) != 0 {
+				server, err := findServer(strings.TrimSpace(args[0]))
+				if err != nil {
+					fmt.Println(utils.ErrorMessage(err.Error()))
+					os.Exit(1)
+				}
+				servers = []*McpServer{server}
+			}
+
+			// If no servers are available, show an error message
+			if len(servers) == 0 {
+				fmt.Println(utils.ErrorBox("No servers are available. Please add a server 🌫️"))
+				os.Exit(0)
+			}
+
+			// Convert the result to markdown format
+			md, err := utils.ToMarkDown(formatToolList(servers), utils.TerminalWidth())
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(fmt.Sprintf("Error caught while converting to markdown: %s", err)))
+				os.Exit(1)
+			}
+
+			fmt.Println(md)
+		},
+	}
+
+	// inspectServerCmd shows the details reported by a MCP server or one of its tools
+	inspectServerCmd = &cobra.Command{
+		Use:   "inspect [server] [tool]",
+		Short: "Inspect a MCP server or one of its tools",
+		Long:  utils.InfoBox("Inspect a MCP server or one of its tools. This command connects to the server and shows the server info, protocol version and capabilities it reports, along with the full input schema of its tools."),
+		Example: `
+		oclai mcp inspect filesystem
+		oclai mcp inspect filesystem read_file
+		`,
+		Args: cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			server, err := findServer(strings.TrimSpace(args[0]))
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(err.Error()))
+				os.Exit(1)
+			}
+
+			toolName := ""
+			if len(args) == 2 {
+				toolName = strings.TrimSpace(args[1])
+			}
+
+			// Connect to the server to retrieve what it reports
+			inspection, err := inspectServer(cmd.Context(), *server)
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(fmt.Sprintf("Error caught while connecting to the server: %s", err)))
+				os.Exit(1)
+			}
+
+			result, err := formatInspection(*server, inspection, toolName)
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(err.Error()))
+				os.Exit(1)
+			}
+
+			// Convert the result to markdown format
+			md, err := utils.ToMarkDown(result, utils.TerminalWidth())
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(fmt.Sprintf("Error caught while converting to markdown: %s", err)))
+				os.Exit(1)
+			}
+
+			fmt.Println(md)
+		},
+	}
+
+	// callToolCmd calls a tool of a MCP server directly
+	callToolCmd = &cobra.Command{
+		Use:   "call [server] [tool]",
+		Short: "Call a tool of a MCP server",
+		Long:  utils.InfoBox("Call a tool of a MCP server directly, without the model. The arguments are validated against the input schema discovered for the tool, and every content block returned by the tool is shown along with its error state and structured content."),
+		Example: `
+		oclai mcp call filesystem list_directory --arg path=.
+		oclai mcp call filesystem read_text_file --arg path=README.md --arg head=10
+		oclai mcp call fetch fetch --json '{"url": "https://example.com", "max_length": 500}' --output json
+		`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			argPairs, _ := cmd.Flags().GetStringArray("arg")
+			rawJSON, _ := cmd.Flags().GetString("json")
+			output, _ := cmd.Flags().GetString("output")
+
+			if output != "text" && output != "json" {
+				fmt.Println(utils.ErrorMessage(fmt.Sprintf("unsupported output format: '%s'. Supported formats: text, json", output)))
+				os.Exit(1)
+			}
+
+			server, err := findServer(strings.TrimSpace(args[0]))
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(err.Error()))
+				os.Exit(1)
+			}
+
+			tool, err := findTool(server, strings.TrimSpace(args[1]))
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(err.Error()))
+				os.Exit(1)
+			}
+
+			// Build the arguments and check them before starting the server
+			toolArgs, err := parseToolArgs(tool, argPairs, rawJSON)
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(err.Error()))
+				os.Exit(1)
+			}
+
+			if err := validateToolArgs(tool, toolArgs); err != nil {
+				fmt.Println(utils.ErrorMessage(err.Error()))
+				os.Exit(1)
+			}
+
+			result, err := callServerTool(cmd.Context(), *server, &goMCP.CallToolParams{
+				Name:      tool.Function.Name,
+				Arguments: toolArgs,
+			})
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(fmt.Sprintf("Error caught while calling the tool: %s", err)))
+				os.Exit(1)
+			}
+
+			// Print the raw result, so it can be used in scripts
+			if output == "json" {
+				data, err := json.MarshalIndent(result, "", "  ")
+				if err != nil {
+					fmt.Println(utils.ErrorMessage(fmt.Sprintf("Error caught while encoding the result: %s", err)))
+					os.Exit(1)
+				}
+				fmt.Println(string(data))
+			} else {
+				md, err := utils.ToMarkDown(formatCallResult(*server, tool.Function.Name, result), utils.TerminalWidth())
+				if err != nil {
+					fmt.Println(utils.ErrorMessage(fmt.Sprintf("Error caught while converting to markdown: %s", err)))
+					os.Exit(1)
+				}
+				fmt.Println(md)
+			}
+
+			// Report the errors of the tool through the exit code
+			if result.IsError {
+				os.Exit(1)
+			}
+		},
+	}
+
+	// refreshServersCmd re-discovers the tools of the MCP servers
+	refreshServersCmd = &cobra.Command{
+		Use:     "refresh",
+		Short:   "Re-discover the tools of the MCP servers",
+		Long:    utils.InfoBox("Re-discover the tools of the MCP servers. This command starts every configured server and updates the cached list of tools they provide."),
+		Example: "oclai mcp refresh",
+		Run: func(cmd *cobra.Command, args []string) {
+			servers := getServers()
+
+			// If no servers are available, show an error message
+			if len(servers) == 0 {
+				fmt.Println(utils.ErrorBox("No servers are available. Please add a server 🌫️"))
+				os.Exit(0)
+			}
+
+			if _, err := InitializeServers(cmd.Context(), rootPath); err != nil {
+				fmt.Println(utils.ErrorMessage(fmt.Sprintf("Error caught while refreshing the servers: %s", err)))
+				os.Exit(1)
+			}
+
+			// Build the result string with the number of tools discovered per server
+			result := "# Discovered Tools\n"
+
+			for _, server := range servers {
+				if !server.isEnabled() {
+					result += fmt.Sprintf("- %s: *disabled*\n", server.Name)
+				} else if server.unavailable {
+					result += fmt.Sprintf("- %s: *unavailable*\n", server.Name)
+				} else {
+					result += fmt.Sprintf("- %s: %d tools\n", server.Name, len(server.Tools))
+				}
+			}
+
+			// Convert the result to markdown format
+			md, err := utils.ToMarkDown(result, utils.TerminalWidth())
+			if err != nil {
+				fmt.Println(utils.ErrorMessage(fmt.Sprintf("Error caught while converting to markdown: %s", err)))
+				os.Exit(1)
+			}
+
+			fmt.Println(md)
+		},
+	}
+
 	// addServerCmd adds a new MCP server with specified configurations
 	addServerCmd = &cobra.Command{
 		Use:   "add",
@@ -167,6 +401,30 @@ var (
 	}
 )
 
+// setServerState enables or disables the server given in the command arguments
+func setServerState(cmd *cobra.Command, args []string, enabled bool) {
+	// Extract the server name from command arguments
+	serverName := strings.TrimSpace(strings.Join(args, " "))
+
+	// Validate that a server name was provided
+	if serverName == "" {
+		fmt.Println(utils.ErrorMessage("Please provide the server name 😒"))
+		os.Exit(1)
+	}
+
+	state := "disabled"
+	if enabled {
+		state = "enabled"
+	}
+
+	if err := setServerEnabled(cmd.Context(), rootPath, serverName, enabled); err != nil {
+		fmt.Println(utils.ErrorMessage(fmt.Sprintf("Error caught while updating the server: %s", err)))
+		os.Exit(1)
+	}
+
+	fmt.Println(utils.SuccessBox(fmt.Sprintf("'%s' server %s successfully!", serverName, state)))
+}
+
 // A helper function to convert cmd args - From an array to map
 func getArrayToMap(arr []string) map[string]string {
 	result := make(map[string]string)
@@ -183,17 +441,8 @@ func getArrayToMap(arr []string) map[string]string {
 }
 
 func init() {
-	// Get application root directory
-	_rootPath, err := utils.GetAppRootDir()
-	if err != nil {
-		fmt.Println(utils.ErrorMessage(fmt.Sprintf("Error caught while retreiving root path: %s", err)))
-		os.Exit(1)
-	}
-
-	rootPath = _rootPath
-
 	// Add sub-commands to mcp root cmd
-	McpRootCmd.AddCommand(listServersCmd, addServerCmd, removeServerCmd)
+	McpRootCmd.AddCommand(listServersCmd, addServerCmd, removeServerCmd, enableServerCmd, disableServerCmd, refreshServersCmd, listToolsCmd, inspectServerCmd, callToolCmd)
 
 	// Register add mcp server command flags
 	addServerCmd.Flags().StringP("name", "n", "", "Server name")
@@ -202,4 +451,10 @@ func init() {
 	addServerCmd.Flags().String("args", "", "Arguments for the server command")
 	addServerCmd.Flags().StringSlice("env", []string{}, "Specify env varriables (comma seperated) to run the server command with")
 	addServerCmd.Flags().StringSlice("headers", []string{}, "Add addition headers varriables (comma seperated) which will be used while connecting to the server")
+
+	// Register call tool command flags
+	callToolCmd.Flags().StringArray("arg", []string{}, "Tool argument as 'key=value', can be repeated. Values are decoded as JSON unless the tool expects a string")
+	callToolCmd.Flags().String("json", "", "Tool arguments as a JSON object")
+	callToolCmd.Flags().StringP("output", "o", "text", "Output format: text or json")
+	callToolCmd.MarkFlagsMutuallyExclusive("arg", "json")
 }
@@ -1,8 +1,13 @@
 package mcp
 
 import (
+	"crypto/sha256"
 	"encoding/json"
+	"fmt"
+	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	goMCP "github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/spf13/viper"
@@ -10,8 +15,13 @@ import (
 	"github.com/thejasmeetsingh/oclai/pkg/utils"
 )
 
-// McpConfigFileName is the name of the configuration file
-const McpConfigFileName = "mcp"
+const (
+	// McpConfigFileName is the name of the configuration file
+	McpConfigFileName = "mcp"
+
+	// projectToolsFileName is the name of the file caching the tools of the project servers
+	projectToolsFileName = "project-tools"
+)
 
 // McpServer represents the MCP configuration structure
 type McpServer struct {
@@ -23,6 +33,24 @@ type McpServer struct {
 	Headers  map[string]string `json:"headers,omitempty"`
 	Env      map[string]string `json:"env,omitempty"`
 	Tools    []ollama.Tool     `json:"tools,omitempty"`
+
+	// Enabled tells whether the tools of the server are offered to the model, the servers are enabled if not set
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// unavailable marks a server which failed to start during the current run
+	unavailable bool
+
+	// isProject marks a server defined by the project-local configuration
+	isProject bool
+
+	// cached marks a project server whose tools were loaded from the cache
+	cached bool
+}
+
+// cachedTools are the tools discovered on a project server, along with the fingerprint of its definition
+type cachedTools struct {
+	Fingerprint string        `json:"fingerprint"`
+	Tools       []ollama.Tool `json:"tools"`
 }
 
 var (
@@ -31,10 +59,23 @@ var (
 
 	// mcpServers map contains all the mcp servers with their respected tool details
 	mcpServers = make(map[string][]*McpServer)
+
+	// rootPath stores the application root directory path, as given when loading the configuration
+	rootPath = ""
+
+	// projectServers contains the mcp servers defined by the project-local configuration.
+	// Their tools are cached apart from the user configuration, by project directory.
+	projectServers = make(map[string][]*McpServer)
+
+	// projectDir is the directory of the project-local configuration, if its servers are loaded
+	projectDir = ""
 )
 
-// LoadConfig initializes and loads the MCP servers configuration
-func LoadConfig(rootPath string) error {
+// LoadConfig initializes and loads the MCP servers configuration.
+// The servers of the project-local configuration are only loaded if the project is trusted.
+func LoadConfig(appRootPath string, trustProject bool) error {
+	rootPath = appRootPath
+
 	// Construct the full path to the configuration file
 	filePath := filepath.Join(rootPath, McpConfigFileName)
 
@@ -65,8 +106,150 @@ func LoadConfig(rootPath string) error {
 		return err
 	}
 
-	// Unmarshal the JSON data into the OclaiConfig struct
-	return json.Unmarshal(data, &mcpServers)
+	// Unmarshal the JSON data into the mcpServers map
+	if err = json.Unmarshal(data, &mcpServers); err != nil {
+		return err
+	}
+
+	return loadProjectServers(trustProject)
+}
+
+// loadProjectServers looks for a project-local configuration from the working directory and loads its servers.
+// The servers of an untrusted project are ignored, since starting them runs the commands of the project.
+func loadProjectServers(trusted bool) error {
+	projectDir = ""
+
+	projectPath, err := utils.FindProjectConfig()
+	if err != nil || projectPath == "" {
+		return err
+	}
+
+	data, err := utils.ReadProjectConfig(projectPath, McpConfigFileName)
+	if err != nil || data == nil {
+		return err
+	}
+
+	if err = json.Unmarshal(data, &projectServers); err != nil {
+		return fmt.Errorf("failed to parse the project MCP servers '%s': %s", projectPath, err)
+	}
+
+	if !trusted {
+		if len(projectServers["servers"]) != 0 {
+			fmt.Fprintln(os.Stderr, utils.ErrorMessage(fmt.Sprintf("Ignoring the MCP servers of the untrusted project '%s', run 'oclai trust' to start them",
+				filepath.Dir(projectPath))))
+		}
+		projectServers = make(map[string][]*McpServer)
+		return nil
+	}
+
+	for _, server := range projectServers["servers"] {
+		server.isProject = true
+	}
+	projectDir = filepath.Dir(projectPath)
+
+	return loadProjectTools()
+}
+
+// fingerprint identifies the definition of the server, so the cached tools are discovered again once it changes
+func (s *McpServer) fingerprint() string {
+	definition := *s
+	definition.Tools = nil
+	definition.Enabled = nil
+
+	data, _ := json.Marshal(definition)
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+// readProjectTools reads the cached tools of the project servers, by project directory and server name
+func readProjectTools() (map[string]map[string]cachedTools, error) {
+	cache := make(map[string]map[string]cachedTools)
+
+	data, err := os.ReadFile(filepath.Join(rootPath, projectToolsFileName))
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, &cache); err != nil {
+		return nil, fmt.Errorf("failed to parse the cached tools of the project servers: %s", err)
+	}
+
+	return cache, nil
+}
+
+// loadProjectTools sets the cached tools of the project servers whose definition did not change
+func loadProjectTools() error {
+	cache, err := readProjectTools()
+	if err != nil {
+		return err
+	}
+
+	for _, server := range projectServers["servers"] {
+		entry, exists := cache[projectDir][server.Name]
+		if exists && entry.Fingerprint == server.fingerprint() {
+			server.Tools = entry.Tools
+			server.cached = true
+		}
+	}
+
+	return nil
+}
+
+// saveProjectTools caches the tools of the project servers discovered during the run,
+// and drops the cached tools of the servers which were removed from the project
+func saveProjectTools() error {
+	if projectDir == "" {
+		return nil
+	}
+
+	cache, err := readProjectTools()
+	if err != nil {
+		return err
+	}
+
+	entries := make(map[string]cachedTools)
+	for _, server := range projectServers["servers"] {
+		if entry, exists := cache[projectDir][server.Name]; exists {
+			entries[server.Name] = entry
+		}
+
+		// Keep the previous tools of the servers which were out of scope or failed to start
+		if isServerInScope(server) {
+			entries[server.Name] = cachedTools{Fingerprint: server.fingerprint(), Tools: server.Tools}
+			server.cached = true
+		}
+	}
+	cache[projectDir] = entries
+
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return utils.WriteFileContents(filepath.Join(rootPath, projectToolsFileName), data)
+}
+
+// isEnabled checks whether the server is enabled in the configuration
+func (s *McpServer) isEnabled() bool {
+	return s.Enabled == nil || *s.Enabled
+}
+
+// getServers returns the servers of the user configuration merged with the project-local ones.
+// A project server takes precedence over a user server with the same name.
+func getServers() []*McpServer {
+	servers := make([]*McpServer, 0)
+
+	for _, server := range mcpServers["servers"] {
+		if !slices.ContainsFunc(projectServers["servers"], func(projectServer *McpServer) bool {
+			return strings.EqualFold(projectServer.Name, server.Name)
+		}) {
+			servers = append(servers, server)
+		}
+	}
+
+	return append(servers, projectServers["servers"]...)
 }
 
 // UpdateConfig updates the MCP configuration file with the current server details
@@ -0,0 +1,128 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLoadProjectServersRequiresTrust(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	dir := t.TempDir()
+	content := `{"mcp": {"servers": [{"name": "project", "command": "echo"}]}}`
+	if err := os.WriteFile(filepath.Join(dir, "oclai.json"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+
+	for _, trusted := range []bool{false, true} {
+		projectServers = make(map[string][]*McpServer)
+
+		if err := loadProjectServers(trusted); err != nil {
+			t.Fatal(err)
+		}
+
+		servers := projectServers["servers"]
+		if !trusted && len(servers) != 0 {
+			t.Errorf("expected the servers of an untrusted project to be ignored, got %d", len(servers))
+		}
+		if trusted && (len(servers) != 1 || !servers[0].isProject) {
+			t.Errorf("expected the project server to be loaded, got %v", servers)
+		}
+	}
+}
+
+// setupProjectServer writes a trusted project configuration defining the given server, and loads it
+func setupProjectServer(t *testing.T, server McpServer) {
+	t.Helper()
+
+	data, err := json.Marshal(map[string]any{"mcp": map[string]any{"servers": []McpServer{server}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile("oclai.json", data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	projectServers = make(map[string][]*McpServer)
+	if err := loadProjectServers(true); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// projectToolNames returns the names of the tools of the project server
+func projectToolNames() []string {
+	var names []string
+	for _, tool := range projectServers["servers"][0].Tools {
+		names = append(names, tool.Function.Name)
+	}
+
+	return names
+}
+
+func TestProjectServerToolsAreCached(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+	setTestServers(t)
+	rootPath = t.TempDir()
+
+	server := testServer(t, "project", 0)
+	ctx := context.Background()
+
+	// The tools are discovered on the first run, then cached
+	setupProjectServer(t, server)
+	if err := InitializeProjectServers(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if names := projectToolNames(); !slices.Equal(names, []string{"echo"}) {
+		t.Fatalf("discovered tools = %v, want [echo]", names)
+	}
+
+	// The next runs use the cache without starting the server
+	cache, err := readProjectTools()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, _ := os.Getwd()
+	entry := cache[dir]["project"]
+	entry.Tools[0].Function.Name = "cached"
+	data, _ := json.Marshal(cache)
+	if err := os.WriteFile(filepath.Join(rootPath, projectToolsFileName), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	setupProjectServer(t, server)
+	if err := InitializeProjectServers(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if names := projectToolNames(); !slices.Equal(names, []string{"cached"}) {
+		t.Fatalf("tools = %v, want the cached ones", names)
+	}
+
+	// A server out of scope is not started, even if its definition changed
+	server.Args = []string{"-test.run=none"}
+	setupProjectServer(t, server)
+	if err := SetRunServers(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := InitializeProjectServers(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if names := projectToolNames(); len(names) != 0 {
+		t.Fatalf("tools = %v, want none for a server out of scope", names)
+	}
+
+	// Once in scope, the changed server is discovered again
+	serverOverrides = make(map[string]bool)
+	if err := InitializeProjectServers(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if names := projectToolNames(); !slices.Equal(names, []string{"echo"}) {
+		t.Fatalf("tools = %v, want the discovered ones", names)
+	}
+}
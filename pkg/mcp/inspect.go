@@ -0,0 +1,125 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	goMCP "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// serverInspection holds what a server reports when connecting, along with the tools it provides
+type serverInspection struct {
+	initResult *goMCP.InitializeResult
+	tools      []*goMCP.Tool
+}
+
+// inspectServer connects to the given server, and retrieves its initialization result and tools
+func inspectServer(ctx context.Context, server McpServer) (*serverInspection, error) {
+	session, err := createSession(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	result, err := session.ListTools(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &serverInspection{
+		initResult: session.InitializeResult(),
+		tools:      result.Tools,
+	}, nil
+}
+
+// jsonBlock renders the given value as an indented JSON code block
+func jsonBlock(value any) string {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("*Failed to encode: %s*\n", err)
+	}
+
+	return fmt.Sprintf("```json\n%s\n```\n", data)
+}
+
+// formatToolList renders the cached tools of the given servers, with their descriptions
+func formatToolList(servers []*McpServer) string {
+	var result strings.Builder
+
+	result.WriteString("# Discovered Tools\n")
+
+	for _, server := range servers {
+		title := server.Name
+		if !server.isEnabled() {
+			title += " (disabled)"
+		}
+		fmt.Fprintf(&result, "\n## %s\n", title)
+
+		if len(server.Tools) == 0 {
+			result.WriteString("*No tools discovered, run `oclai mcp refresh` to discover them*\n")
+			continue
+		}
+
+		for _, tool := range server.Tools {
+			description := strings.Join(strings.Fields(tool.Function.Description), " ")
+			fmt.Fprintf(&result, "- **%s**: %s\n", tool.Function.Name, description)
+		}
+	}
+
+	return result.String()
+}
+
+// formatInspection renders the details reported by a server, or by one of its tools if a tool name is given
+func formatInspection(server McpServer, inspection *serverInspection, toolName string) (string, error) {
+	var result strings.Builder
+
+	tools := inspection.tools
+	if toolName != "" {
+		tools = nil
+		for _, tool := range inspection.tools {
+			if strings.EqualFold(tool.Name, toolName) {
+				tools = append(tools, tool)
+			}
+		}
+
+		if len(tools) == 0 {
+			return "", fmt.Errorf("'%s' tool does not exists on '%s' server", toolName, server.Name)
+		}
+	}
+
+	fmt.Fprintf(&result, "# %s\n", server.Name)
+
+	if toolName == "" {
+		if info := inspection.initResult.ServerInfo; info != nil {
+			fmt.Fprintf(&result, "- **Server:** %s %s\n", info.Name, info.Version)
+		}
+		fmt.Fprintf(&result, "- **Protocol version:** %s\n", inspection.initResult.ProtocolVersion)
+		fmt.Fprintf(&result, "- **Tools:** %d\n", len(inspection.tools))
+
+		if inspection.initResult.Instructions != "" {
+			fmt.Fprintf(&result, "\n## Instructions\n%s\n", inspection.initResult.Instructions)
+		}
+
+		result.WriteString("\n## Capabilities\n")
+		result.WriteString(jsonBlock(inspection.initResult.Capabilities))
+	}
+
+	for _, tool := range tools {
+		fmt.Fprintf(&result, "\n## %s\n", tool.Name)
+		if tool.Description != "" {
+			fmt.Fprintf(&result, "%s\n", tool.Description)
+		}
+
+		result.WriteString("\n**Input schema**\n")
+		result.WriteString(jsonBlock(tool.InputSchema))
+
+		if tool.OutputSchema != nil {
+			result.WriteString("\n**Output schema**\n")
+			result.WriteString(jsonBlock(tool.OutputSchema))
+		}
+	}
+
+	return result.String(), nil
+}
@@ -0,0 +1,65 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	goMCP "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// testServerEnv tells the test binary to run as a MCP server over stdio, after the given delay
+const testServerEnv = "OCLAI_TEST_MCP_SERVER_DELAY"
+
+func TestMain(m *testing.M) {
+	if delay, ok := os.LookupEnv(testServerEnv); ok {
+		runTestServer(delay)
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+// runTestServer serves an "echo" tool over stdio, once the given delay has elapsed
+func runTestServer(delay string) {
+	if duration, err := time.ParseDuration(delay); err == nil {
+		time.Sleep(duration)
+	}
+
+	server := goMCP.NewServer(&goMCP.Implementation{Name: "test", Version: "v0.0.1"}, nil)
+	server.AddTool(&goMCP.Tool{
+		Name:        "echo",
+		Description: "Echo the given text",
+		InputSchema: &jsonschema.Schema{
+			Type:       "object",
+			Properties: map[string]*jsonschema.Schema{"text": {Type: "string"}},
+			Required:   []string{"text"},
+		},
+	}, func(ctx context.Context, req *goMCP.CallToolRequest) (*goMCP.CallToolResult, error) {
+		args, _ := json.Marshal(req.Params.Arguments)
+		return &goMCP.CallToolResult{
+			Content: []goMCP.Content{&goMCP.TextContent{Text: string(args)}},
+		}, nil
+	})
+
+	server.Run(context.Background(), &goMCP.StdioTransport{})
+}
+
+// testServer returns the configuration of a server running the test binary, which starts after the given delay
+func testServer(t *testing.T, name string, delay time.Duration) McpServer {
+	t.Helper()
+
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return McpServer{
+		Name:    name,
+		Command: executable,
+		Env:     map[string]string{testServerEnv: delay.String()},
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"strings"
+
+	"github.com/thejasmeetsingh/oclai/pkg/utils"
 )
 
 // getDefaultServers returns the default list of servers configured for MCP
@@ -42,59 +44,105 @@ func getDefaultServers() []McpServer {
 	}
 }
 
-// InitializeServers sets up the MCP servers with the given context and root path
-func InitializeServers(ctx context.Context, rootPath string) error {
-	// Retrieve the servers configuration from the mcpServers map
-	servers := mcpServers["servers"]
+// initializeServer starts the given server and caches the tools it provides
+func initializeServer(ctx context.Context, server *McpServer) error {
+	// Check if both Command and Endpoint are provided (at least one is required)
+	if server.Command == "" && server.Endpoint == "" {
+		return fmt.Errorf("no transport is provided for '%s' server", server.Name)
+	}
 
-	for _, server := range servers {
-		// Check if both Command and Endpoint are provided (at least one is required)
-		if server.Command == "" && server.Endpoint == "" {
-			return fmt.Errorf("no transport is provided for '%s' server", server.Name)
-		}
+	// Ensure Args is initialized if it's empty
+	if len(server.Args) == 0 {
+		server.Args = make([]string, 0)
+	}
 
-		// Ensure Args is initialized if it's empty
-		if len(server.Args) == 0 {
-			server.Args = make([]string, 0)
-		}
+	// Ensure Headers is initialized if it's empty
+	if len(server.Headers) == 0 {
+		server.Headers = make(map[string]string)
+	}
 
-		// Ensure Headers is initialized if it's empty
-		if len(server.Headers) == 0 {
-			server.Headers = make(map[string]string)
-		}
+	// Ensure Env is initialized if it's empty
+	if len(server.Env) == 0 {
+		server.Env = make(map[string]string)
+	}
 
-		// Ensure Env is initialized if it's empty
-		if len(server.Env) == 0 {
-			server.Env = make(map[string]string)
-		}
+	// Create a session for the server
+	session, err := createSession(ctx, *server)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	// List the available tools for the server
+	tools, err := listTools(ctx, session)
+	if err != nil {
+		return err
+	}
+
+	// If tools are available, add them to the server configuration
+	if len(tools) != 0 {
+		server.Tools = tools
+	}
+
+	return nil
+}
+
+// initializeServerList initializes the given servers, disabling the ones which fail to start.
+// The servers out of scope for the run, e.g. the disabled ones, are not started and their cached tools are kept.
+// It tells whether all the enabled servers were initialized.
+func initializeServerList(ctx context.Context, servers []*McpServer) bool {
+	complete := true
 
-		// Create a session for the server
-		session, err := createSession(ctx, *server)
-		if err != nil {
-			return err
+	for _, server := range servers {
+		server.unavailable = false
+
+		if !isServerInScope(server) {
+			complete = complete && !server.isEnabled()
+			continue
 		}
 
-		// List the available tools for the server
-		tools, err := listTools(ctx, session)
-		if err != nil {
-			return err
+		if err := initializeServer(ctx, server); err != nil {
+			server.unavailable = true
+			fmt.Println(utils.ErrorMessage(fmt.Sprintf("'%s' server is unavailable for this run, failed to start: %s", server.Name, err)))
 		}
+	}
+
+	return complete
+}
 
-		// If tools are available, add them to the server configuration
-		if len(tools) != 0 {
-			server.Tools = tools
+// InitializeProjectServers discovers the tools of the servers defined by the project-local configuration,
+// unless they are cached: only the servers in scope which are new or whose definition changed are started.
+func InitializeProjectServers(ctx context.Context) error {
+	pending := make([]*McpServer, 0)
+	for _, server := range projectServers["servers"] {
+		if !server.cached {
+			pending = append(pending, server)
 		}
+	}
 
-		session.Close()
+	if len(pending) == 0 {
+		return nil
 	}
 
+	initializeServerList(ctx, pending)
+	return saveProjectTools()
+}
+
+// InitializeServers sets up the MCP servers in scope for the run with the given context and root path.
+// A server which fails to start is reported as a warning and disabled for the current run,
+// so that one broken server does not prevent using the others.
+// It tells whether all the enabled servers were initialized, none of them being out of scope.
+func InitializeServers(ctx context.Context, rootPath string) (bool, error) {
+	// Initialize the servers of the user configuration along with the project ones
+	complete := initializeServerList(ctx, getServers())
+
 	// Update the configuration with the current settings
 	err := UpdateConfig(rootPath)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	return nil
+	return complete, saveProjectTools()
 }
 
 // isServerExists checks if a server with the given name already exists
@@ -118,7 +166,8 @@ func addServer(rootPath string, mcpServer McpServer) error {
 
 	// Add the new server to the servers list
 	mcpServers["servers"] = append(mcpServers["servers"], &mcpServer)
-	return InitializeServers(context.Background(), rootPath)
+	_, err := InitializeServers(context.Background(), rootPath)
+	return err
 }
 
 // removeServer removes a server from the configuration
@@ -131,16 +180,43 @@ func removeServer(rootPath, serverName string) error {
 
 	// Remove the server from the servers list
 	mcpServers["servers"] = append(mcpServers["servers"][:idx], mcpServers["servers"][idx+1:]...)
-	return InitializeServers(context.Background(), rootPath)
+	_, err := InitializeServers(context.Background(), rootPath)
+	return err
 }
 
-// getServerList returns a list of server names
+// setServerEnabled enables or disables a server of the user configuration.
+// The tools of an enabled server are discovered if they were never cached.
+func setServerEnabled(ctx context.Context, rootPath, serverName string, enabled bool) error {
+	idx := isServerExists(serverName)
+	if idx == -1 {
+		return fmt.Errorf("server with '%s' name does not exists", serverName)
+	}
+
+	server := mcpServers["servers"][idx]
+	server.Enabled = &enabled
+
+	if enabled && len(server.Tools) == 0 {
+		initializeServerList(ctx, []*McpServer{server})
+	}
+
+	return UpdateConfig(rootPath)
+}
+
+// getServerList returns a list of server names, the project-local and disabled servers are marked as such
 func getServerList() []string {
 	servers := make([]string, 0)
 
 	// Iterate over each server and collect their names
-	for _, server := range mcpServers["servers"] {
-		servers = append(servers, server.Name)
+	for _, server := range getServers() {
+		name := server.Name
+		if server.isProject {
+			name += " (project)"
+		}
+		if !server.isEnabled() {
+			name += " (disabled)"
+		}
+
+		servers = append(servers, name)
 	}
 
 	return servers
@@ -0,0 +1,98 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+)
+
+// setTestServers replaces the configured servers for the duration of the test, with no run selection
+func setTestServers(t *testing.T, servers ...McpServer) []*McpServer {
+	t.Helper()
+
+	configured := make([]*McpServer, 0, len(servers))
+	for idx := range servers {
+		configured = append(configured, &servers[idx])
+	}
+
+	mcpServers = map[string][]*McpServer{"servers": configured}
+	projectServers = make(map[string][]*McpServer)
+	serverScope = nil
+	serverOverrides = make(map[string]bool)
+
+	t.Cleanup(func() {
+		mcpServers = make(map[string][]*McpServer)
+		serverScope = nil
+		serverOverrides = make(map[string]bool)
+	})
+
+	return configured
+}
+
+func TestInitializeServerListSkipsServersOutOfScope(t *testing.T) {
+	disabled := false
+
+	tests := []struct {
+		name         string
+		runServers   []string // Servers selected for the run, as by '--mcp', if not nil
+		noTools      bool     // No server selected, as by '--no-tools'
+		scope        []string // Servers enabled by the profile
+		wantStarted  []bool
+		wantComplete bool
+	}{
+		{
+			name:         "all the enabled servers",
+			wantStarted:  []bool{true, true, false},
+			wantComplete: true,
+		},
+		{
+			name:         "no tools",
+			noTools:      true,
+			wantStarted:  []bool{false, false, false},
+			wantComplete: false,
+		},
+		{
+			name:         "selected server",
+			runServers:   []string{"second"},
+			wantStarted:  []bool{false, true, false},
+			wantComplete: false,
+		},
+		{
+			name:         "selected disabled server",
+			runServers:   []string{"disabled"},
+			wantStarted:  []bool{false, false, true},
+			wantComplete: false,
+		},
+		{
+			name:         "profile scope",
+			scope:        []string{"first"},
+			wantStarted:  []bool{true, false, false},
+			wantComplete: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			off := testServer(t, "disabled", 0)
+			off.Enabled = &disabled
+			servers := setTestServers(t, testServer(t, "first", 0), testServer(t, "second", 0), off)
+
+			SetServerScope(tt.scope)
+			if tt.noTools || tt.runServers != nil {
+				if err := SetRunServers(tt.runServers); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			complete := initializeServerList(context.Background(), getServers())
+			if complete != tt.wantComplete {
+				t.Errorf("complete = %t, want %t", complete, tt.wantComplete)
+			}
+
+			for idx, server := range servers {
+				if started := len(server.Tools) != 0; started != tt.wantStarted[idx] {
+					t.Errorf("'%s' server started = %t, want %t", server.Name, started, tt.wantStarted[idx])
+				}
+			}
+		})
+	}
+}
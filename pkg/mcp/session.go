@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
 
 	goMCP "github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -30,6 +31,115 @@ func (t *customTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	return t.underlyingTransport.RoundTrip(req)
 }
 
+// sessionPool keeps a single client session per MCP server for the lifetime of the process.
+// Servers are started lazily on their first use and the sessions are reused across tool calls.
+type sessionPool struct {
+	mu      sync.Mutex
+	entries map[string]*poolEntry
+}
+
+// poolEntry is the session of a server, which is ready once the server has started.
+// The pool is not locked while a server starts, so a slow server does not block the calls to the others.
+type poolEntry struct {
+	ready   chan struct{} // Closed once the server has started, or failed to
+	session *goMCP.ClientSession
+	err     error
+}
+
+// pool is the process wide MCP session pool
+var pool = &sessionPool{entries: make(map[string]*poolEntry)}
+
+// isReady checks whether the server of the entry has started, or failed to
+func (e *poolEntry) isReady() bool {
+	select {
+	case <-e.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+// get returns the session of the given server, starting the server if it is not running yet.
+// The concurrent calls for a server which is starting wait for it, instead of starting it again.
+func (p *sessionPool) get(ctx context.Context, server McpServer) (*goMCP.ClientSession, error) {
+	p.mu.Lock()
+	entry, ok := p.entries[server.Name]
+	if !ok {
+		entry = &poolEntry{ready: make(chan struct{})}
+		p.entries[server.Name] = entry
+	}
+	p.mu.Unlock()
+
+	if !ok {
+		p.start(ctx, server, entry)
+	}
+
+	select {
+	case <-entry.ready:
+		return entry.session, entry.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// start starts the server of the given entry, the entry is removed from the pool if the server fails to start
+func (p *sessionPool) start(ctx context.Context, server McpServer, entry *poolEntry) {
+	defer close(entry.ready)
+
+	entry.session, entry.err = createSession(ctx, server)
+	if entry.err != nil {
+		p.mu.Lock()
+		if p.entries[server.Name] == entry {
+			delete(p.entries, server.Name)
+		}
+		p.mu.Unlock()
+		return
+	}
+
+	// Forget the session once the server goes away, so that it is restarted on the next call
+	session := entry.session
+	go func() {
+		session.Wait()
+		p.forget(server.Name, session)
+	}()
+}
+
+// forget removes the given session of a server from the pool, if it is still the current one.
+func (p *sessionPool) forget(name string, session *goMCP.ClientSession) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if entry, ok := p.entries[name]; ok && entry.isReady() && entry.session == session {
+		delete(p.entries, name)
+	}
+}
+
+// close shuts down the given session and removes it from the pool.
+func (p *sessionPool) close(name string, session *goMCP.ClientSession) {
+	p.forget(name, session)
+	session.Close()
+}
+
+// closeAll shuts down every running server, waiting for the ones which are starting.
+func (p *sessionPool) closeAll() {
+	p.mu.Lock()
+	entries := p.entries
+	p.entries = make(map[string]*poolEntry)
+	p.mu.Unlock()
+
+	for _, entry := range entries {
+		<-entry.ready
+		if entry.session != nil {
+			entry.session.Close()
+		}
+	}
+}
+
+// CloseSessions shuts down all the MCP servers started by the current process.
+func CloseSessions() {
+	pool.closeAll()
+}
+
 // getEnv processes an environment map and returns a slice of strings suitable for passing to a command.
 // It handles environment variables that start with $ by expanding them using os.Getenv.
 // It also formats the environment variables according to whether the command is Docker or not.
@@ -0,0 +1,83 @@
+package mcp
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSessionPoolReusesSessions(t *testing.T) {
+	p := &sessionPool{entries: make(map[string]*poolEntry)}
+	defer p.closeAll()
+
+	server := testServer(t, "echo", 0)
+	ctx := context.Background()
+
+	// The concurrent calls for a starting server share its session
+	sessions := make(chan any, 3)
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			session, err := p.get(ctx, server)
+			if err != nil {
+				t.Error(err)
+			}
+			sessions <- session
+		}()
+	}
+	wg.Wait()
+	close(sessions)
+
+	first := <-sessions
+	for session := range sessions {
+		if session != first {
+			t.Fatal("expected a single session for the server")
+		}
+	}
+}
+
+func TestSessionPoolDoesNotBlockOnSlowServer(t *testing.T) {
+	p := &sessionPool{entries: make(map[string]*poolEntry)}
+	defer p.closeAll()
+
+	slow := testServer(t, "slow", 2*time.Second)
+	fast := testServer(t, "fast", 0)
+
+	go p.get(context.Background(), slow)
+
+	// Wait for the slow server to be starting
+	for {
+		p.mu.Lock()
+		_, starting := p.entries[slow.Name]
+		p.mu.Unlock()
+		if starting {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if _, err := p.get(ctx, fast); err != nil {
+		t.Fatalf("fast server blocked by the slow one: %s", err)
+	}
+}
+
+func TestSessionPoolForgetsFailedServers(t *testing.T) {
+	p := &sessionPool{entries: make(map[string]*poolEntry)}
+	server := McpServer{Name: "missing", Command: "oclai-missing-command"}
+
+	for range 2 {
+		if _, err := p.get(context.Background(), server); err == nil {
+			t.Fatal("expected the server to fail to start")
+		}
+	}
+
+	if len(p.entries) != 0 {
+		t.Errorf("expected the failed server to be removed from the pool, got %d entries", len(p.entries))
+	}
+}
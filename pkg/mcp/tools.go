@@ -3,7 +3,9 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 
 	goMCP "github.com/modelcontextprotocol/go-sdk/mcp"
@@ -14,10 +16,7 @@ import (
 // to the ollama.Tool format for compatibility.
 // It handles the conversion of tool parameters from JSON format to the ollama.Parameter type.
 func listTools(ctx context.Context, cs *goMCP.ClientSession) ([]ollama.Tool, error) {
-	var (
-		tools  []ollama.Tool
-		params ollama.Parameter
-	)
+	var tools []ollama.Tool
 
 	// Fetch the list of tools from the MCP client
 	mcpTools, err := cs.ListTools(ctx, nil)
@@ -33,7 +32,8 @@ func listTools(ctx context.Context, cs *goMCP.ClientSession) ([]ollama.Tool, err
 			return tools, err
 		}
 
-		// Unmarshal the JSON into the ollama.Parameter type
+		// Unmarshal the JSON into the ollama.Parameter type, a fresh value so the properties of the tools are not merged
+		var params ollama.Parameter
 		err = json.Unmarshal(inputSchema, &params)
 		if err != nil {
 			return tools, err
@@ -53,8 +53,33 @@ func listTools(ctx context.Context, cs *goMCP.ClientSession) ([]ollama.Tool, err
 	return tools, nil
 }
 
+// ToolError represents an error reported by a tool itself, such as an invalid argument.
+// Unlike transport failures, these errors are meant to be fed back to the model so it can recover.
+type ToolError struct {
+	Message string
+}
+
+// Error implements the error interface
+func (e *ToolError) Error() string {
+	return e.Message
+}
+
+// getTextContent extracts the text from the content blocks returned by a tool
+func getTextContent(contents []goMCP.Content) []string {
+	var texts []string
+
+	for _, content := range contents {
+		if textContent, ok := content.(*goMCP.TextContent); ok {
+			texts = append(texts, textContent.Text)
+		}
+	}
+
+	return texts
+}
+
 // CallTool executes a specific tool using the MCP client session and returns the results.
 // It handles the execution of the tool and processes the results to return them as a string.
+// Errors reported by the tool are returned as a *ToolError carrying the server's error content.
 func CallTool(ctx context.Context, cs *goMCP.ClientSession, params *goMCP.CallToolParams) (string, error) {
 	// Execute the tool with the provided parameters
 	result, err := cs.CallTool(ctx, params)
@@ -62,51 +87,199 @@ func CallTool(ctx context.Context, cs *goMCP.ClientSession, params *goMCP.CallTo
 		return "", err
 	}
 
-	// If the tool execution resulted in an error, return an error message
-	if result.IsError {
-		return "", fmt.Errorf("tool execution failed")
-	}
-
 	// Extract the text content from the tool's result
-	var toolResults []string
-	for _, content := range result.Content {
-		toolResults = append(toolResults, content.(*goMCP.TextContent).Text)
+	toolResults := getTextContent(result.Content)
+
+	// If the tool execution resulted in an error, return the error content sent by the server
+	if result.IsError {
+		message := strings.Join(toolResults, "\n")
+		if message == "" {
+			message = "tool execution failed"
+		}
+		return "", &ToolError{Message: message}
 	}
 
 	// Join the results into a single string and return
 	return strings.Join(toolResults, "."), nil
 }
 
+var (
+	// serverScope restricts the tools offered to the model to the servers it contains, if not empty
+	serverScope []string
+
+	// serverOverrides turns the servers on or off for the current run, by lowercased name.
+	// It takes precedence over the enabled field of the servers and the scope.
+	serverOverrides = make(map[string]bool)
+)
+
+// SetServerScope restricts the tools offered to the model to the given servers.
+// An empty list enables the tools of all the servers.
+func SetServerScope(servers []string) {
+	serverScope = servers
+}
+
+// findServer returns the server with the given name, case-insensitively
+func findServer(name string) (*McpServer, error) {
+	for _, server := range getServers() {
+		if strings.EqualFold(server.Name, name) {
+			return server, nil
+		}
+	}
+
+	return nil, fmt.Errorf("server with '%s' name does not exists", name)
+}
+
+// SetRunServers offers the tools of the given servers only for the current run, even if they are disabled.
+// An empty list offers no tools at all.
+func SetRunServers(names []string) error {
+	for _, name := range names {
+		if _, err := findServer(name); err != nil {
+			return err
+		}
+	}
+
+	for _, server := range getServers() {
+		serverOverrides[strings.ToLower(server.Name)] = slices.ContainsFunc(names, func(name string) bool {
+			return strings.EqualFold(name, server.Name)
+		})
+	}
+
+	return nil
+}
+
+// SetServerOverride turns the tools of the given server on or off for the current run
+func SetServerOverride(name string, on bool) error {
+	server, err := findServer(name)
+	if err != nil {
+		return err
+	}
+
+	if on && server.unavailable {
+		return fmt.Errorf("'%s' server is unavailable, it failed to start", server.Name)
+	}
+
+	if on && len(server.Tools) == 0 {
+		return fmt.Errorf("'%s' server has no discovered tools, enable it with 'oclai mcp enable %s'", server.Name, server.Name)
+	}
+
+	serverOverrides[strings.ToLower(server.Name)] = on
+	return nil
+}
+
+// ServerState describes whether the tools of a server are offered to the model in the current run
+type ServerState struct {
+	Name   string
+	Active bool
+	Tools  int
+}
+
+// GetServerStates returns the state of every server in the current run
+func GetServerStates() []ServerState {
+	states := make([]ServerState, 0)
+	for _, server := range getServers() {
+		states = append(states, ServerState{
+			Name:   server.Name,
+			Active: isServerInScope(server),
+			Tools:  len(server.Tools),
+		})
+	}
+
+	return states
+}
+
+// isServerInScope checks whether the tools of the given server can be offered to the model
+func isServerInScope(server *McpServer) bool {
+	if server.unavailable {
+		return false
+	}
+
+	if on, exists := serverOverrides[strings.ToLower(server.Name)]; exists {
+		return on
+	}
+
+	if !server.isEnabled() {
+		return false
+	}
+
+	if len(serverScope) == 0 {
+		return true
+	}
+
+	return slices.ContainsFunc(serverScope, func(name string) bool {
+		return strings.EqualFold(name, server.Name)
+	})
+}
+
 // GetAllTools returns all available tools from the MCP servers.
-// It aggregates tools from all servers to provide a comprehensive list.
+// It aggregates tools from all servers in scope to provide a comprehensive list.
 func GetAllTools() []ollama.Tool {
 	tools := make([]ollama.Tool, 0)
-	servers := mcpServers["servers"]
+	servers := getServers()
 
 	for _, server := range servers {
+		// Skip the servers which failed to start or are out of scope
+		if !isServerInScope(server) {
+			continue
+		}
+
 		tools = append(tools, server.Tools...)
 	}
 
 	return tools
 }
 
-// GetSessionFromToolName retrieves a MCP client session for the specified tool name.
-func GetSessionFromToolName(ctx context.Context, toolName string) (*goMCP.ClientSession, error) {
-	servers := mcpServers["servers"]
+// getServerFromToolName returns the server which provides the specified tool.
+func getServerFromToolName(toolName string) (*McpServer, error) {
+	servers := getServers()
 
-	// Search for the tool across all servers
+	// Search for the tool across all the servers in scope
 	for _, server := range servers {
+		if !isServerInScope(server) {
+			continue
+		}
+
 		for _, tool := range server.Tools {
 			if strings.EqualFold(tool.Function.Name, toolName) {
-				// Create a session for the found server
-				session, err := createSession(ctx, *server)
-				if err != nil {
-					return nil, err
-				}
-				return session, nil
+				return server, nil
 			}
 		}
 	}
 
-	return nil, fmt.Errorf("'%s' tool does not exists", toolName)
+	return nil, &ToolError{Message: fmt.Sprintf("'%s' tool does not exists", toolName)}
+}
+
+// GetToolServerName returns the name of the server which provides the specified tool,
+// or an empty string if no server in scope provides it.
+func GetToolServerName(toolName string) string {
+	server, err := getServerFromToolName(toolName)
+	if err != nil {
+		return ""
+	}
+
+	return server.Name
+}
+
+// CallToolByName executes the specified tool on the server which provides it.
+// If the server connection turns out to be closed (e.g. the server crashed),
+// the server is restarted and the call is retried once.
+func CallToolByName(ctx context.Context, params *goMCP.CallToolParams) (string, error) {
+	server, err := getServerFromToolName(params.Name)
+	if err != nil {
+		return "", err
+	}
+
+	for attempt := 0; ; attempt++ {
+		session, err := pool.get(ctx, *server)
+		if err != nil {
+			return "", err
+		}
+
+		result, err := CallTool(ctx, session, params)
+		if errors.Is(err, goMCP.ErrConnectionClosed) && attempt == 0 {
+			pool.close(server.Name, session)
+			continue
+		}
+
+		return result, err
+	}
 }
@@ -2,8 +2,10 @@ package ollama
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 
@@ -27,11 +29,19 @@ func CheckOllamaConnection(url string) error {
 	return nil
 }
 
-// SystemPromptMessage returns a system message for the assistant
-func SystemPromptMessage() Message {
+// DefaultSystemPrompt is the system prompt used when none is configured
+const DefaultSystemPrompt = "You are a helpful assistant. You are designed to be helpful, honest, and safe."
+
+// SystemPromptMessage returns a system message for the assistant with the given prompt,
+// falling back to the default prompt if it is empty
+func SystemPromptMessage(prompt string) Message {
+	if prompt == "" {
+		prompt = DefaultSystemPrompt
+	}
+
 	return Message{
 		Role:    SystemRole,
-		Content: "You are a helpful assistant. You are designed to be helpful, honest, and safe.",
+		Content: prompt,
 	}
 }
 
@@ -85,11 +95,12 @@ func ShowModels(url string, models *[]ModelInfo) (string, error) {
 
 	// Format the content with a header and join the model messages
 	content := fmt.Sprintf("# 📋 Available Models\n%s", strings.Join(modelDetails, "\n"))
-	return utils.ToMarkDown(content)
+	return utils.ToMarkDown(content, utils.TerminalWidth())
 }
 
-// Chat sends a chat request to the Ollama service and returns the response
-func Chat(url string, request ModelRequest) (*ModelResponse, error) {
+// postChat sends a POST request with the given chat request to the 'chat' endpoint.
+// The request is bound to the given context, so cancelling it aborts the generation.
+func postChat(ctx context.Context, url string, request ModelRequest) (*http.Response, error) {
 	// Create a buffer to hold the request body
 	body := &bytes.Buffer{}
 
@@ -97,8 +108,19 @@ func Chat(url string, request ModelRequest) (*ModelResponse, error) {
 	encoder := json.NewEncoder(body)
 	encoder.Encode(request)
 
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url+"/api/chat", body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return http.DefaultClient.Do(req)
+}
+
+// Chat sends a chat request to the Ollama service and returns the response
+func Chat(ctx context.Context, url string, request ModelRequest) (*ModelResponse, error) {
 	// Send a POST request to the 'chat' endpoint
-	response, err := http.Post(url+"/api/chat", "application/json", body)
+	response, err := postChat(ctx, url, request)
 	if err != nil {
 		return nil, err
 	}
@@ -121,6 +143,70 @@ func Chat(url string, request ModelRequest) (*ModelResponse, error) {
 	return nil, fmt.Errorf("no response is returned from ollama service")
 }
 
+// ChatStream sends a streaming chat request to the Ollama service.
+// Each partial message received from the NDJSON stream is passed to onChunk (if provided),
+// and the accumulated response is returned once the stream is done.
+func ChatStream(ctx context.Context, url string, request ModelRequest, onChunk func(Message)) (*ModelResponse, error) {
+	request.Stream = true
+
+	// Send a POST request to the 'chat' endpoint
+	response, err := postChat(ctx, url, request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	// Check if the response status code is HTTP 200 OK
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API request failed with status %d", response.StatusCode)
+	}
+
+	var (
+		content   strings.Builder
+		thinking  strings.Builder
+		toolCalls []ToolCall
+	)
+
+	// Decode each JSON object of the stream until the final chunk is received
+	decoder := json.NewDecoder(response.Body)
+	for {
+		var chunk ModelResponse
+		if err = decoder.Decode(&chunk); err != nil {
+			// Report a cancelled request as such rather than as a parsing failure
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if err == io.EOF {
+				return nil, fmt.Errorf("no response is returned from ollama service")
+			}
+			return nil, fmt.Errorf("error while parsing the model response: %s", err.Error())
+		}
+
+		if chunk.Error != "" {
+			return nil, fmt.Errorf("%s", chunk.Error)
+		}
+
+		content.WriteString(chunk.Message.Content)
+		thinking.WriteString(chunk.Message.Thinking)
+		toolCalls = append(toolCalls, chunk.Message.ToolCalls...)
+
+		if onChunk != nil && (chunk.Message.Content != "" || chunk.Message.Thinking != "") {
+			onChunk(chunk.Message)
+		}
+
+		if chunk.Done {
+			// The final chunk carries the stats, so only the message needs to be assembled
+			chunk.Message = Message{
+				Role:      AssistantRole,
+				Content:   content.String(),
+				Thinking:  thinking.String(),
+				ToolCalls: toolCalls,
+			}
+			return &chunk, nil
+		}
+	}
+}
+
 // A util function to check if the given model exists or not.
 func IsModelExists(url, model string, models *[]ModelInfo) (bool, error) {
 	// If no models are provided, fetch them from the Ollama service
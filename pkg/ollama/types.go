@@ -15,6 +15,9 @@ type (
 		ParameterType string         `json:"type"`
 		Properties    map[string]any `json:"properties"`
 		Required      []string       `json:"required"`
+
+		// AdditionalProperties is either a boolean or the schema of the properties which are not listed
+		AdditionalProperties any `json:"additionalProperties,omitempty"`
 	}
 
 	Function struct {
@@ -68,6 +71,7 @@ type (
 		PromptEvalDuration int64     `json:"prompt_eval_duration,omitempty"`
 		EvalCount          int       `json:"eval_count,omitempty"`
 		EvalDuration       int64     `json:"eval_duration,omitempty"`
+		Error              string    `json:"error,omitempty"`
 	}
 
 	// ModelInfo contains information about a model
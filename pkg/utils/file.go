@@ -2,9 +2,13 @@ package utils
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strings"
 )
 
 // Constants for directory and file permissions
@@ -12,6 +16,9 @@ const (
 	// rootDirName is the name of the root directory used for application data
 	rootDirName = ".oclai"
 
+	// projectFileName is the name of the single file alternative to a project '.oclai' directory
+	projectFileName = "oclai.json"
+
 	// dirWritePerm is the permission mode for creating directories
 	dirWritePerm = 0755
 
@@ -19,6 +26,9 @@ const (
 	fileWritePerm = 0644
 )
 
+// projectSections are the keys of an 'oclai.json' project file, one for each configuration file of a '.oclai' directory
+var projectSections = []string{"config", "mcp"}
+
 // createAppDir creates the application root directory if it doesn't exist
 func createAppDir(appRootPath string) error {
 	// Check if the directory exists
@@ -48,6 +58,92 @@ func GetAppRootDir() (string, error) {
 	return appRootPath, nil
 }
 
+// FindProjectConfig walks up from the current working directory looking for a project-local
+// configuration: either a '.oclai' directory or an 'oclai.json' file. It returns the path of the
+// first one found, or an empty string if there is none. The user's application root directory
+// is never considered as a project configuration, nor an unrelated 'oclai.json' file, e.g. a package manifest.
+func FindProjectConfig() (string, error) {
+	appRootPath, err := GetAppRootDir()
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		dirPath := filepath.Join(dir, rootDirName)
+		if info, err := os.Stat(dirPath); err == nil && info.IsDir() && dirPath != appRootPath {
+			return dirPath, nil
+		}
+
+		filePath := filepath.Join(dir, projectFileName)
+		if isProjectFile(filePath) {
+			return filePath, nil
+		}
+
+		// Stop once the filesystem root is reached
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// isProjectFile checks whether the given file is an 'oclai.json' project file, holding at least one of the project sections
+func isProjectFile(filePath string) bool {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return false
+	}
+
+	var sections map[string]json.RawMessage
+	if err = json.Unmarshal(data, &sections); err != nil {
+		return false
+	}
+
+	for _, section := range projectSections {
+		if _, exists := sections[section]; exists {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ReadProjectConfig reads the given section of a project-local configuration found by FindProjectConfig.
+// For a '.oclai' directory the section is read from the file with the given name, while for an
+// 'oclai.json' file the section is the value of the given key. It returns nil if the section is missing.
+func ReadProjectConfig(projectPath, section string) ([]byte, error) {
+	info, err := os.Stat(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		data, err := os.ReadFile(filepath.Join(projectPath, section))
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return data, err
+	}
+
+	data, err := os.ReadFile(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var sections map[string]json.RawMessage
+	if err = json.Unmarshal(data, &sections); err != nil {
+		return nil, fmt.Errorf("failed to parse '%s': %s", projectPath, err)
+	}
+
+	return sections[section], nil
+}
+
 // ReadConfig reads the contents of a configuration file
 func ReadConfig(filePath string) ([]byte, error) {
 	data, err := os.ReadFile(filePath)
@@ -117,3 +213,25 @@ func ReadPipedInput() ([]string, error) {
 
 	return result, nil
 }
+
+// GetEditorCmd returns the command which opens the given file in the user's editor.
+// The editor is taken from the VISUAL or EDITOR environment variables, with a platform default as fallback.
+func GetEditorCmd(filePath string) *exec.Cmd {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	// The editor may be configured along with its arguments, e.g. 'code --wait'
+	args := strings.Fields(editor)
+	args = append(args, filePath)
+
+	return exec.Command(args[0], args[1:]...)
+}
@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindProjectConfig(t *testing.T) {
+	manifest := `{"version": "1.0.7", "bin": "oclai.exe"}`
+
+	tests := []struct {
+		name  string
+		files map[string]string // Files of the tree by path, an empty content creates a directory
+		want  string            // Expected project configuration, relative to the root of the tree
+	}{
+		{
+			name:  "project directory",
+			files: map[string]string{".oclai/": ""},
+			want:  ".oclai",
+		},
+		{
+			name:  "project file with the config section",
+			files: map[string]string{"repo/oclai.json": `{"config": {"defaultModel": "qwen3:8b"}}`},
+			want:  "repo/oclai.json",
+		},
+		{
+			name:  "project file with the mcp section",
+			files: map[string]string{"repo/oclai.json": `{"mcp": {"servers": []}}`},
+			want:  "repo/oclai.json",
+		},
+		{
+			name:  "package manifest does not shadow a parent project",
+			files: map[string]string{".oclai/": "", "repo/oclai.json": manifest},
+			want:  ".oclai",
+		},
+		{
+			name:  "invalid file does not shadow a parent project",
+			files: map[string]string{".oclai/": "", "repo/oclai.json": "not json"},
+			want:  ".oclai",
+		},
+		{
+			name:  "package manifest only",
+			files: map[string]string{"repo/oclai.json": manifest},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+
+			root := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(root, name)
+				if content == "" {
+					if err := os.MkdirAll(path, 0755); err != nil {
+						t.Fatal(err)
+					}
+					continue
+				}
+
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			workDir := filepath.Join(root, "repo", "src")
+			if err := os.MkdirAll(workDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			t.Chdir(workDir)
+
+			got, err := FindProjectConfig()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := ""
+			if tt.want != "" {
+				want = filepath.Join(root, tt.want)
+			}
+
+			if got != want {
+				t.Errorf("FindProjectConfig() = %q, want %q", got, want)
+			}
+		})
+	}
+}
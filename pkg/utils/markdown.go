@@ -1,15 +1,28 @@
 package utils
 
 import (
+	"strings"
+
 	"github.com/charmbracelet/glamour"
 )
 
+// CodeBlock wraps the given content in a code block, with a fence longer than any backtick sequence of the content
+func CodeBlock(content string) string {
+	fence := "```"
+	for strings.Contains(content, fence) {
+		fence += "`"
+	}
+
+	return fence + "\n" + content + "\n" + fence
+}
+
 // ToMarkDown converts the given content into Markdown format using the glamour library.
-func ToMarkDown(content string) (string, error) {
+// The text is wrapped at the given width, or not wrapped at all if the width is zero.
+func ToMarkDown(content string, wrapWidth int) (string, error) {
 	// Create a new TermRenderer with specific styling and formatting options.
 	renderer, err := glamour.NewTermRenderer(
 		glamour.WithStandardStyle("dark"), // Applies a dark-themed style to the rendered content.
-		glamour.WithWordWrap(100),         // Wraps the text at 100 characters per line for better readability.
+		glamour.WithWordWrap(wrapWidth),   // Wraps the text at the given width for better readability.
 	)
 	if err != nil {
 		return "", err
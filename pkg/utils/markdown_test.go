@@ -0,0 +1,24 @@
+package utils
+
+import "testing"
+
+func TestCodeBlock(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"plain text", "hello", "```\nhello\n```"},
+		{"inline backticks", "use `go test`", "```\nuse `go test`\n```"},
+		{"nested fence", "```go\nfunc main() {}\n```", "````\n```go\nfunc main() {}\n```\n````"},
+		{"longer fence", "````", "`````\n````\n`````"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CodeBlock(tt.content); got != tt.want {
+				t.Errorf("CodeBlock(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
@@ -142,6 +142,17 @@ var (
 			BorderStyle(lipgloss.RoundedBorder()).
 			BorderForeground(Theme.secondary).
 			BorderLeft(true)
+
+	toolMsgBoxStyle = lipgloss.NewStyle().
+			Padding(0, 1).
+			Margin(1, 0).
+			BorderStyle(lipgloss.RoundedBorder()).
+			BorderForeground(Theme.accent).
+			BorderLeft(true)
+
+	toolHeaderStyle = lipgloss.NewStyle().
+			Foreground(Theme.accent).
+			Bold(true)
 )
 
 // Message formatting functions
@@ -181,3 +192,7 @@ func UserMsgBox(timestamp, message string) string {
 func AiMsgBox(timestamp, message string) string {
 	return aiMsgBoxStyle.Render(fmt.Sprintf("\n[%s] 🤖:\n%s", timestamp, message))
 }
+
+func ToolMsgBox(header, message string) string {
+	return toolMsgBoxStyle.Render(toolHeaderStyle.Render(header) + "\n" + message)
+}
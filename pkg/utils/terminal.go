@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"os"
+
+	"golang.org/x/term"
+)
+
+// IsTerminal checks whether the given file is a terminal
+func IsTerminal(file *os.File) bool {
+	return term.IsTerminal(int(file.Fd()))
+}
+
+// TerminalWidth returns the width of the terminal attached to stdout,
+// or zero if stdout is not a terminal, e.g. when the output is piped
+func TerminalWidth() int {
+	width, _ := TerminalSize()
+	return width
+}
+
+// TerminalSize returns the width and height of the terminal attached to stdout,
+// or zeros if stdout is not a terminal
+func TerminalSize() (int, int) {
+	if !IsTerminal(os.Stdout) {
+		return 0, 0
+	}
+
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		return 0, 0
+	}
+
+	return width, height
+}